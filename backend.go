@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+
+	"github.com/AdrienFromToulouse/eudi/srs"
+)
+
+// CompileForPlonk compiles circuit into the sparse-R1CS constraint system
+// PlonkBackend.Setup/Prove/Verify expect. It's the PLONK analogue of
+// frontend.Compile(ecc.BN254.ScalarField(), r1csBuilder, circuit), the way
+// InitCircuit/InitPolicyCircuit/InitRecursiveCircuit compile for
+// Groth16Backend; feeding those functions' R1CS output to a PlonkBackend
+// instead panics inside gnark, since PLONK's commitments aren't shaped like
+// Groth16's.
+func CompileForPlonk(circuit frontend.Circuit) (constraint.ConstraintSystem, error) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scsBuilder, circuit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile circuit for plonk: %v", err)
+	}
+	return ccs, nil
+}
+
+// Scheme identifies which gnark proving system produced a ProofEnvelope.
+type Scheme string
+
+const (
+	SchemeGroth16BN254 Scheme = "groth16-bn254"
+	SchemePlonkBN254   Scheme = "plonk-bn254"
+)
+
+// ProofEnvelope wraps a proof's raw gnark binary encoding together with the
+// scheme that produced it. Credential.Proof carries one of these instead
+// of a bare *groth16.Proof, so a verifier picks the matching Backend by
+// inspecting Scheme rather than assuming Groth16.
+type ProofEnvelope struct {
+	Scheme Scheme `json:"scheme"`
+	Bytes  []byte `json:"bytes"`
+}
+
+// Backend abstracts a gnark proving system's Setup/Prove/Verify, so a
+// circuit can be proved under either Groth16 (InitCircuit's per-circuit
+// trusted setup) or PLONK (a universal SRS reused across every
+// dynamically-built policy circuit) without its caller caring which.
+type Backend interface {
+	Scheme() Scheme
+	Setup(ccs constraint.ConstraintSystem) error
+	Prove(ccs constraint.ConstraintSystem, fullWitness witness.Witness) (ProofEnvelope, error)
+	Verify(envelope ProofEnvelope, publicWitness witness.Witness) error
+}
+
+// Groth16Backend runs a fresh per-circuit Groth16 trusted setup, the same
+// way InitCircuit always has.
+type Groth16Backend struct {
+	pk groth16.ProvingKey
+	vk groth16.VerifyingKey
+}
+
+// NewGroth16Backend returns a Groth16Backend with no keys yet; call Setup
+// before Prove or Verify.
+func NewGroth16Backend() *Groth16Backend {
+	return &Groth16Backend{}
+}
+
+func (b *Groth16Backend) Scheme() Scheme { return SchemeGroth16BN254 }
+
+func (b *Groth16Backend) Setup(ccs constraint.ConstraintSystem) error {
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		return fmt.Errorf("groth16 setup failed: %v", err)
+	}
+	b.pk, b.vk = pk, vk
+	return nil
+}
+
+func (b *Groth16Backend) Prove(ccs constraint.ConstraintSystem, fullWitness witness.Witness) (ProofEnvelope, error) {
+	proof, err := groth16.Prove(ccs, b.pk, fullWitness)
+	if err != nil {
+		return ProofEnvelope{}, fmt.Errorf("groth16 prove failed: %v", err)
+	}
+	return encodeGroth16Proof(proof)
+}
+
+func (b *Groth16Backend) Verify(envelope ProofEnvelope, publicWitness witness.Witness) error {
+	proof, err := decodeGroth16Proof(envelope)
+	if err != nil {
+		return err
+	}
+	return groth16.Verify(proof, b.vk, publicWitness)
+}
+
+// encodeGroth16Proof serializes proof into a Groth16-scheme ProofEnvelope.
+func encodeGroth16Proof(proof groth16.Proof) (ProofEnvelope, error) {
+	var buf bytes.Buffer
+	if _, err := proof.WriteTo(&buf); err != nil {
+		return ProofEnvelope{}, fmt.Errorf("failed to encode groth16 proof: %v", err)
+	}
+	return ProofEnvelope{Scheme: SchemeGroth16BN254, Bytes: buf.Bytes()}, nil
+}
+
+// decodeGroth16Proof is the inverse of encodeGroth16Proof.
+func decodeGroth16Proof(envelope ProofEnvelope) (groth16.Proof, error) {
+	if envelope.Scheme != SchemeGroth16BN254 {
+		return nil, fmt.Errorf("expected a %q proof, got %q", SchemeGroth16BN254, envelope.Scheme)
+	}
+	proof := groth16.NewProof(ecc.BN254)
+	if _, err := proof.ReadFrom(bytes.NewReader(envelope.Bytes)); err != nil {
+		return nil, fmt.Errorf("failed to decode groth16 proof: %v", err)
+	}
+	return proof, nil
+}
+
+// PlonkBackend proves against a KZG SRS loaded once via srs.Load and
+// reused across every dynamically-built policy circuit (see
+// CircuitBuilder), avoiding the fresh trusted ceremony Groth16Backend needs
+// per circuit shape.
+type PlonkBackend struct {
+	srs srs.KZG
+
+	pk plonk.ProvingKey
+	vk plonk.VerifyingKey
+}
+
+// NewPlonkBackend returns a PlonkBackend backed by an already-loaded SRS;
+// call Setup once per circuit shape before Prove or Verify.
+func NewPlonkBackend(kzgSRS srs.KZG) *PlonkBackend {
+	return &PlonkBackend{srs: kzgSRS}
+}
+
+func (b *PlonkBackend) Scheme() Scheme { return SchemePlonkBN254 }
+
+func (b *PlonkBackend) Setup(ccs constraint.ConstraintSystem) error {
+	pk, vk, err := plonk.Setup(ccs, &b.srs.Canonical, &b.srs.Lagrange)
+	if err != nil {
+		return fmt.Errorf("plonk setup failed: %v", err)
+	}
+	b.pk, b.vk = pk, vk
+	return nil
+}
+
+func (b *PlonkBackend) Prove(ccs constraint.ConstraintSystem, fullWitness witness.Witness) (ProofEnvelope, error) {
+	proof, err := plonk.Prove(ccs, b.pk, fullWitness)
+	if err != nil {
+		return ProofEnvelope{}, fmt.Errorf("plonk prove failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := proof.WriteTo(&buf); err != nil {
+		return ProofEnvelope{}, fmt.Errorf("failed to encode plonk proof: %v", err)
+	}
+	return ProofEnvelope{Scheme: SchemePlonkBN254, Bytes: buf.Bytes()}, nil
+}
+
+func (b *PlonkBackend) Verify(envelope ProofEnvelope, publicWitness witness.Witness) error {
+	if envelope.Scheme != SchemePlonkBN254 {
+		return fmt.Errorf("expected a %q proof, got %q", SchemePlonkBN254, envelope.Scheme)
+	}
+	proof := plonk.NewProof(ecc.BN254)
+	if _, err := proof.ReadFrom(bytes.NewReader(envelope.Bytes)); err != nil {
+		return fmt.Errorf("failed to decode plonk proof: %v", err)
+	}
+	return plonk.Verify(proof, b.vk, publicWitness)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bn254kzg "github.com/consensys/gnark-crypto/ecc/bn254/kzg"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+
+	"github.com/AdrienFromToulouse/eudi/issuer"
+	"github.com/AdrienFromToulouse/eudi/srs"
+)
+
+// newTestKZG derives a freshly toxic-waste-generated KZG SRS sized for ccs,
+// the same way srs.Load derives its canonical/Lagrange pair from a ceremony
+// file, so PlonkBackend tests don't depend on an on-disk Powers-of-Tau
+// transcript.
+func newTestKZG(t *testing.T, ccs constraint.ConstraintSystem) srs.KZG {
+	t.Helper()
+
+	size := ecc.NextPowerOfTwo(uint64(ccs.GetNbConstraints() + ccs.GetNbPublicVariables()))
+
+	alpha, err := rand.Int(rand.Reader, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatalf("sample toxic waste: %v", err)
+	}
+	canonical, err := bn254kzg.NewSRS(size+3, alpha)
+	if err != nil {
+		t.Fatalf("bn254kzg.NewSRS: %v", err)
+	}
+	lagrangeG1, err := bn254kzg.ToLagrangeG1(canonical.Pk.G1[:size])
+	if err != nil {
+		t.Fatalf("bn254kzg.ToLagrangeG1: %v", err)
+	}
+
+	return srs.KZG{
+		Canonical: *canonical,
+		Lagrange:  bn254kzg.SRS{Pk: bn254kzg.ProvingKey{G1: lagrangeG1}, Vk: canonical.Vk},
+	}
+}
+
+// TestPlonkBackendRoundTrip proves and verifies an AgeCheckCircuit under
+// PlonkBackend. It guards against PlonkBackend.Setup being fed an R1CS
+// built for Groth16 (what InitCircuit/InitPolicyCircuit/InitRecursiveCircuit
+// all compile): PLONK needs the sparse-R1CS CompileForPlonk produces.
+func TestPlonkBackendRoundTrip(t *testing.T) {
+	iss, err := issuer.New("did:example:test-issuer")
+	if err != nil {
+		t.Fatalf("issuer.New: %v", err)
+	}
+
+	var circuit AgeCheckCircuit
+	ccs, err := CompileForPlonk(&circuit)
+	if err != nil {
+		t.Fatalf("CompileForPlonk: %v", err)
+	}
+
+	backend := NewPlonkBackend(newTestKZG(t, ccs))
+	if err := backend.Setup(ccs); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+
+	revocation, err := buildRevocationWitness(iss, "urn:uuid:plonk-test")
+	if err != nil {
+		t.Fatalf("buildRevocationWitness: %v", err)
+	}
+	assignment := &AgeCheckCircuit{BirthYear: 1984, CurrentYear: 2024, Revocation: *revocation}
+
+	fullWitness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatalf("frontend.NewWitness: %v", err)
+	}
+
+	envelope, err := backend.Prove(ccs, fullWitness)
+	if err != nil {
+		t.Fatalf("Prove: %v", err)
+	}
+
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		t.Fatalf("Public: %v", err)
+	}
+
+	if err := backend.Verify(envelope, publicWitness); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+}
+
+// TestPlonkBackendPolicyPresentation proves and verifies a PolicyCircuit
+// presentation under PlonkBackend, the actual motivation InitPolicyCircuit's
+// Backend parameter exists for: PolicyCircuit's shape is fixed regardless of
+// policy length, so a verifier can reuse one PlonkBackend setup across every
+// policy instead of Groth16Backend's fresh per-shape trusted ceremony.
+func TestPlonkBackendPolicyPresentation(t *testing.T) {
+	wallet, err := NewWallet()
+	if err != nil {
+		t.Fatalf("NewWallet: %v", err)
+	}
+
+	iss, err := issuer.New("did:example:test-issuer")
+	if err != nil {
+		t.Fatalf("issuer.New: %v", err)
+	}
+
+	policy := []PresentationPolicy{
+		{Attribute: "givenName", Disclosed: true},
+		{
+			Attribute:    "birthYear",
+			Predicate:    PredicateRange,
+			PublicParams: map[string]interface{}{"min": int64(1900), "max": int64(2010)},
+		},
+	}
+	subject := map[string]interface{}{
+		"givenName": "Adrien",
+		"birthYear": "1984",
+	}
+
+	cred, err := wallet.IssuePolicyCredential(policy, subject)
+	if err != nil {
+		t.Fatalf("IssuePolicyCredential: %v", err)
+	}
+
+	var probeCircuit PolicyCircuit
+	probeCcs, err := CompileForPlonk(&probeCircuit)
+	if err != nil {
+		t.Fatalf("CompileForPlonk: %v", err)
+	}
+
+	backend := NewPlonkBackend(newTestKZG(t, probeCcs))
+	ccs, err := InitPolicyCircuit(policy, backend)
+	if err != nil {
+		t.Fatalf("InitPolicyCircuit: %v", err)
+	}
+
+	envelope, circuit, err := wallet.Present(&cred, policy, backend, ccs, iss)
+	if err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+
+	statusList, err := iss.PublishStatusList()
+	if err != nil {
+		t.Fatalf("PublishStatusList: %v", err)
+	}
+
+	ok, err := VerifyPresentation(circuit, envelope, backend, statusList, iss)
+	if err != nil {
+		t.Fatalf("VerifyPresentation: %v", err)
+	}
+	if !ok {
+		t.Fatal("VerifyPresentation reported an invalid proof")
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+
+	"go.etcd.io/bbolt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+var (
+	credentialsBucket = []byte("credentials")
+	artifactsBucket   = []byte("artifacts")
+	metaBucket        = []byte("meta")
+	kdfSaltKey        = []byte("kdf_salt")
+)
+
+// BoltStore is the default persistent Store: a BoltDB file holding
+// credentials, their attribute salts, and raw key/constraint-system
+// artifacts, every record encrypted at rest under a passphrase-derived key.
+type BoltStore struct {
+	db  *bbolt.DB
+	key [chacha20poly1305.KeySize]byte
+}
+
+// NewBoltStore opens (creating if necessary) a BoltDB file at path,
+// deriving its encryption key from passphrase via Argon2id against a
+// random salt persisted alongside the data on first use.
+func NewBoltStore(path, passphrase string) (*BoltStore, error) {
+	db, err := bbolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bolt store at %s: %v", path, err)
+	}
+
+	var kdfSalt []byte
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range [][]byte{credentialsBucket, artifactsBucket, metaBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+
+		meta := tx.Bucket(metaBucket)
+		kdfSalt = meta.Get(kdfSaltKey)
+		if kdfSalt == nil {
+			kdfSalt = make([]byte, 16)
+			if _, err := rand.Read(kdfSalt); err != nil {
+				return fmt.Errorf("failed to generate KDF salt: %v", err)
+			}
+			if err := meta.Put(kdfSaltKey, kdfSalt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	derived := argon2.IDKey([]byte(passphrase), kdfSalt, 1, 64*1024, 4, chacha20poly1305.KeySize)
+
+	store := &BoltStore{db: db}
+	copy(store.key[:], derived)
+	return store, nil
+}
+
+// Close releases the underlying BoltDB file handle.
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *BoltStore) seal(plaintext []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(s.key[:])
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (s *BoltStore) unseal(ciphertext []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(s.key[:])
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, data := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, data, nil)
+}
+
+// credentialRecord is the plaintext shape encrypted under each
+// credentials-bucket entry: the credential itself plus whatever
+// per-attribute salts back its commitment.
+type credentialRecord struct {
+	Credential Credential `json:"credential"`
+	Salts      []*big.Int `json:"salts,omitempty"`
+}
+
+func (s *BoltStore) Put(cred Credential, salts []*big.Int) error {
+	plaintext, err := json.Marshal(credentialRecord{Credential: cred, Salts: salts})
+	if err != nil {
+		return fmt.Errorf("failed to encode credential: %v", err)
+	}
+	ciphertext, err := s.seal(plaintext)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt credential: %v", err)
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(credentialsBucket).Put([]byte(cred.ID), ciphertext)
+	})
+}
+
+func (s *BoltStore) Get(id string) (Credential, []*big.Int, error) {
+	var record credentialRecord
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		raw := tx.Bucket(credentialsBucket).Get([]byte(id))
+		if raw == nil {
+			return fmt.Errorf("no credential with id %q", id)
+		}
+		plaintext, err := s.unseal(raw)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt credential: %v", err)
+		}
+		return json.Unmarshal(plaintext, &record)
+	})
+	if err != nil {
+		return Credential{}, nil, err
+	}
+	return record.Credential, record.Salts, nil
+}
+
+func (s *BoltStore) List() ([]Credential, error) {
+	var out []Credential
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(credentialsBucket).ForEach(func(_, raw []byte) error {
+			plaintext, err := s.unseal(raw)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt credential: %v", err)
+			}
+			var record credentialRecord
+			if err := json.Unmarshal(plaintext, &record); err != nil {
+				return err
+			}
+			out = append(out, record.Credential)
+			return nil
+		})
+	})
+	return out, err
+}
+
+func (s *BoltStore) Delete(id string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(credentialsBucket).Delete([]byte(id))
+	})
+}
+
+func (s *BoltStore) Query(policy []PresentationPolicy) ([]Credential, error) {
+	all, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	return filterByPolicy(all, policy), nil
+}
+
+func (s *BoltStore) PutArtifact(name string, artifact io.WriterTo) error {
+	var buf bytes.Buffer
+	if _, err := artifact.WriteTo(&buf); err != nil {
+		return fmt.Errorf("failed to serialize artifact %q: %v", name, err)
+	}
+	ciphertext, err := s.seal(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to encrypt artifact %q: %v", name, err)
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(artifactsBucket).Put([]byte(name), ciphertext)
+	})
+}
+
+func (s *BoltStore) GetArtifact(name string, artifact io.ReaderFrom) error {
+	return s.db.View(func(tx *bbolt.Tx) error {
+		raw := tx.Bucket(artifactsBucket).Get([]byte(name))
+		if raw == nil {
+			return fmt.Errorf("no artifact named %q", name)
+		}
+		plaintext, err := s.unseal(raw)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt artifact %q: %v", name, err)
+		}
+		_, err = artifact.ReadFrom(bytes.NewReader(plaintext))
+		return err
+	})
+}
@@ -0,0 +1,232 @@
+// Package issuer implements the revocation layer for eudi credentials: an
+// Issuer maintains a Merkle tree of revoked credential IDs and publishes
+// the root as a signed status list, so holders can prove non-revocation
+// in-circuit without contacting the issuer at verification time.
+package issuer
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	bn254mimc "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+)
+
+// TreeDepth is the depth of the revocation Merkle tree, fixed so the
+// in-circuit non-revocation gadget has a constant shape.
+const TreeDepth = 8
+
+const maxLeaves = 1 << TreeDepth
+
+// fieldMax is the largest value a leaf can take (the BN254 scalar field
+// modulus minus one), used as the +inf sentinel so every real credential
+// hash sorts below it.
+var fieldMax = func() *big.Int {
+	m, _ := new(big.Int).SetString("21888242871839275222246405745257275088548364400416034343698204186575808495617", 10)
+	return m.Sub(m, big.NewInt(1))
+}()
+
+// Issuer maintains the revocation state for credentials it has issued.
+type Issuer struct {
+	DID        string
+	signingKey *ecdsa.PrivateKey
+
+	// revoked holds the MiMC hash of every revoked credential ID, always
+	// kept sorted so a non-revocation proof can point at the two leaves a
+	// target hash would sort between.
+	revoked []*big.Int
+}
+
+// New creates an Issuer with a freshly generated status-list signing key.
+func New(did string) (*Issuer, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate issuer signing key: %v", err)
+	}
+	return &Issuer{DID: did, signingKey: key}, nil
+}
+
+// HashCredentialID hashes a credential ID with the same MiMC hash the
+// non-revocation gadget uses in-circuit, so off-circuit and in-circuit
+// views of the revocation set always agree.
+func HashCredentialID(credentialID string) *big.Int {
+	h := bn254mimc.NewMiMC()
+	h.Write([]byte(credentialID))
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+// Revoke adds credentialID to the revocation set. It is a no-op if the
+// credential is already revoked.
+func (iss *Issuer) Revoke(credentialID string) error {
+	if len(iss.revoked) >= maxLeaves-2 {
+		return fmt.Errorf("revocation tree is full (max %d entries)", maxLeaves-2)
+	}
+
+	h := HashCredentialID(credentialID)
+	idx := sort.Search(len(iss.revoked), func(i int) bool { return iss.revoked[i].Cmp(h) >= 0 })
+	if idx < len(iss.revoked) && iss.revoked[idx].Cmp(h) == 0 {
+		return nil
+	}
+
+	iss.revoked = append(iss.revoked, nil)
+	copy(iss.revoked[idx+1:], iss.revoked[idx:])
+	iss.revoked[idx] = h
+	return nil
+}
+
+// leaves returns the full, fixed-size leaf set backing the Merkle tree: a
+// -inf sentinel, every revoked hash in sorted order, then +inf sentinels
+// padding out to maxLeaves.
+func (iss *Issuer) leaves() []*big.Int {
+	out := make([]*big.Int, 0, maxLeaves)
+	out = append(out, big.NewInt(0))
+	out = append(out, iss.revoked...)
+	for len(out) < maxLeaves {
+		out = append(out, new(big.Int).Set(fieldMax))
+	}
+	return out
+}
+
+// fieldBytes returns v's big-endian encoding as a fixed-width,
+// bn254mimc.BlockSize-sized block. big.Int.Bytes strips leading zero bytes,
+// and in particular returns an empty slice for 0 (the -inf sentinel leaf);
+// hash.Hash.Write silently drops an empty write instead of hashing a zero
+// block, which would desync this off-circuit tree from the in-circuit
+// Merkle gadget's fixed-width field-element writes.
+func fieldBytes(v *big.Int) []byte {
+	buf := make([]byte, bn254mimc.BlockSize)
+	v.FillBytes(buf)
+	return buf
+}
+
+func hashPair(a, b *big.Int) *big.Int {
+	h := bn254mimc.NewMiMC()
+	h.Write(fieldBytes(a))
+	h.Write(fieldBytes(b))
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+// Root computes the current Merkle root over the issuer's revocation set.
+func (iss *Issuer) Root() *big.Int {
+	level := iss.leaves()
+	for len(level) > 1 {
+		next := make([]*big.Int, 0, len(level)/2)
+		for i := 0; i < len(level); i += 2 {
+			next = append(next, hashPair(level[i], level[i+1]))
+		}
+		level = next
+	}
+	return level[0]
+}
+
+// MerkleProof is an inclusion path for a single leaf, plus the leaf's value
+// and index, sufficient to recompute Root() in-circuit.
+type MerkleProof struct {
+	Index    int
+	Leaf     *big.Int
+	Siblings [TreeDepth]*big.Int
+}
+
+func (iss *Issuer) proofFor(index int) MerkleProof {
+	level := iss.leaves()
+	proof := MerkleProof{Index: index, Leaf: level[index]}
+
+	idx := index
+	for d := 0; d < TreeDepth; d++ {
+		proof.Siblings[d] = level[idx^1]
+
+		next := make([]*big.Int, 0, len(level)/2)
+		for i := 0; i < len(level); i += 2 {
+			next = append(next, hashPair(level[i], level[i+1]))
+		}
+		level = next
+		idx /= 2
+	}
+
+	return proof
+}
+
+// NonRevocationProof returns the pair of adjacent Merkle proofs a holder
+// needs to prove credentialID is absent from the revocation set: the
+// leaves immediately below and above where its hash would sort.
+func (iss *Issuer) NonRevocationProof(credentialID string) (low, high MerkleProof, err error) {
+	h := HashCredentialID(credentialID)
+	idx := sort.Search(len(iss.revoked), func(i int) bool { return iss.revoked[i].Cmp(h) >= 0 })
+	if idx < len(iss.revoked) && iss.revoked[idx].Cmp(h) == 0 {
+		return MerkleProof{}, MerkleProof{}, fmt.Errorf("credential %s is revoked", credentialID)
+	}
+
+	return iss.proofFor(idx), iss.proofFor(idx + 1), nil
+}
+
+// StatusListValidity bounds how long a verifier may treat a signed
+// StatusListCredential as fresh. Verifiers are meant to cache a published
+// status list instead of contacting the issuer on every verification, but
+// without an expiry a holder revoked after IssuedAt could replay the list
+// they saved before revocation forever; PublishStatusList sets NextUpdate
+// to IssuedAt plus this window, and VerifyStatusList rejects anything past
+// it.
+const StatusListValidity = 24 * time.Hour
+
+// StatusListCredential is the signed attestation of the current revocation
+// root, published so verifiers can check non-revocation proofs without
+// contacting the issuer for every verification. The signature covers Root
+// and NextUpdate together, so neither can be altered independently of the
+// other without invalidating it.
+type StatusListCredential struct {
+	Issuer     string
+	Root       *big.Int
+	IssuedAt   time.Time
+	NextUpdate time.Time
+	Signature  []byte
+}
+
+// PublishStatusList signs the current revocation root, valid until
+// StatusListValidity from now.
+func (iss *Issuer) PublishStatusList() (StatusListCredential, error) {
+	root := iss.Root()
+	issuedAt := time.Now()
+	nextUpdate := issuedAt.Add(StatusListValidity)
+	sig, err := ecdsa.SignASN1(rand.Reader, iss.signingKey, statusListDigest(root, nextUpdate))
+	if err != nil {
+		return StatusListCredential{}, fmt.Errorf("failed to sign status list: %v", err)
+	}
+	return StatusListCredential{
+		Issuer:     iss.DID,
+		Root:       root,
+		IssuedAt:   issuedAt,
+		NextUpdate: nextUpdate,
+		Signature:  sig,
+	}, nil
+}
+
+// PublicKey returns the issuer's status-list signing public key, for
+// verifiers that check StatusListCredential.Signature themselves.
+func (iss *Issuer) PublicKey() *ecdsa.PublicKey {
+	return &iss.signingKey.PublicKey
+}
+
+// VerifyStatusList reports whether list was signed by pub and hasn't passed
+// its NextUpdate deadline.
+func VerifyStatusList(list StatusListCredential, pub *ecdsa.PublicKey) bool {
+	if time.Now().After(list.NextUpdate) {
+		return false
+	}
+	return ecdsa.VerifyASN1(pub, statusListDigest(list.Root, list.NextUpdate), list.Signature)
+}
+
+// statusListDigest is the message a StatusListCredential's Signature covers:
+// the revocation root and its NextUpdate deadline together, so a verifier
+// can't be fed a validly-signed root under an attacker-extended expiry.
+func statusListDigest(root *big.Int, nextUpdate time.Time) []byte {
+	digest := make([]byte, 0, bn254mimc.BlockSize+8)
+	digest = append(digest, fieldBytes(root)...)
+	var nu [8]byte
+	binary.BigEndian.PutUint64(nu[:], uint64(nextUpdate.Unix()))
+	return append(digest, nu[:]...)
+}
@@ -0,0 +1,72 @@
+package issuer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRevokeAndNonRevocationProof(t *testing.T) {
+	iss, err := New("did:example:test-issuer")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	rootBefore := iss.Root()
+
+	if _, _, err := iss.NonRevocationProof("urn:uuid:unrevoked"); err != nil {
+		t.Fatalf("NonRevocationProof on an unrevoked credential: %v", err)
+	}
+
+	if err := iss.Revoke("urn:uuid:revoked"); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+
+	if iss.Root().Cmp(rootBefore) == 0 {
+		t.Fatal("Root didn't change after Revoke")
+	}
+
+	if _, _, err := iss.NonRevocationProof("urn:uuid:revoked"); err == nil {
+		t.Fatal("NonRevocationProof succeeded for a revoked credential, want an error")
+	}
+
+	// Revoking the same credential twice is a no-op, not an error.
+	if err := iss.Revoke("urn:uuid:revoked"); err != nil {
+		t.Fatalf("Revoke (duplicate): %v", err)
+	}
+}
+
+func TestPublishAndVerifyStatusList(t *testing.T) {
+	iss, err := New("did:example:test-issuer")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	list, err := iss.PublishStatusList()
+	if err != nil {
+		t.Fatalf("PublishStatusList: %v", err)
+	}
+
+	if !VerifyStatusList(list, iss.PublicKey()) {
+		t.Fatal("VerifyStatusList rejected a freshly published status list")
+	}
+
+	other, err := New("did:example:other-issuer")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if VerifyStatusList(list, other.PublicKey()) {
+		t.Fatal("VerifyStatusList accepted a status list against the wrong issuer's key")
+	}
+
+	tamperedRoot := list
+	tamperedRoot.Root = HashCredentialID("urn:uuid:forged")
+	if VerifyStatusList(tamperedRoot, iss.PublicKey()) {
+		t.Fatal("VerifyStatusList accepted a status list with a tampered root")
+	}
+
+	expired := list
+	expired.NextUpdate = time.Now().Add(-time.Minute)
+	if VerifyStatusList(expired, iss.PublicKey()) {
+		t.Fatal("VerifyStatusList accepted a status list past its NextUpdate")
+	}
+}
@@ -1,12 +1,20 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"log"
+	"math/big"
+	"sync"
 	"time"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+
+	"github.com/AdrienFromToulouse/eudi/issuer"
 )
 
 // Credential represents a simplified Verifiable Credential (VC)
@@ -16,29 +24,139 @@ type Credential struct {
 	Issuer       string                 `json:"issuer"`
 	IssuanceDate time.Time              `json:"issuanceDate"`
 	Subject      map[string]interface{} `json:"credentialSubject"`
-	Proof        *groth16.Proof         `json:"proof"`
-}
+	Proof        *ProofEnvelope         `json:"proof"`
 
-// Proof represents the cryptographic proof for the VC
-type Proof struct {
-	Type                string    `json:"type"`
-	Created             time.Time `json:"created"`
-	ProofSignatureValue string    `json:"proofSignatureValue"`
+	// Commitment binds every Subject attribute (value + per-attribute salt)
+	// at issuance time, so a later presentation can prove predicates over
+	// hidden attributes without the issuer being involved. Populated by
+	// IssuePolicyCredential; nil for credentials issued via IssueCredential.
+	Commitment *big.Int `json:"commitment,omitempty"`
+
+	// nbPublic/nbSecret record Proof's circuit's public/secret variable
+	// counts at the time it was produced, so MarshalJSON can describe the
+	// witness shape a verifier needs without access to the Go circuit type.
+	nbPublic, nbSecret int
 }
 
-// Wallet stores user credentials
+// Wallet stores user credentials behind a pluggable Store, so holder state
+// survives process restarts instead of living only in a slice.
 type Wallet struct {
-	Credentials []Credential
+	store Store
+
+	keyCache   bool
+	artifactMu sync.RWMutex
+	artifacts  map[string][]byte // plaintext artifact cache, populated only when keyCache is enabled
+}
+
+// Option configures a Wallet constructed via NewWallet.
+type Option func(*walletConfig)
+
+type walletConfig struct {
+	store      Store
+	passphrase string
+	keyCache   bool
 }
 
-func NewWallet() (*Wallet, error) {
+// WithStore backs the wallet with a custom Store implementation instead of
+// the default in-memory or BoltDB-backed one.
+func WithStore(store Store) Option {
+	return func(c *walletConfig) { c.store = store }
+}
+
+// WithPassphrase opens the wallet's default BoltDB-backed Store at
+// defaultStorePath, encrypted under a key derived from passphrase. Ignored
+// if WithStore is also given.
+func WithPassphrase(passphrase string) Option {
+	return func(c *walletConfig) { c.passphrase = passphrase }
+}
+
+// WithKeyCache keeps decrypted proving/verifying keys and constraint
+// systems in memory after their first load, avoiding repeated Argon2id
+// derivation and decryption for every proof generated in a session.
+func WithKeyCache(enabled bool) Option {
+	return func(c *walletConfig) { c.keyCache = enabled }
+}
+
+// defaultStorePath is where NewWallet opens its default BoltDB store when
+// WithPassphrase is given without an explicit WithStore.
+const defaultStorePath = "wallet.db"
+
+func NewWallet(opts ...Option) (*Wallet, error) {
+	cfg := &walletConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	store := cfg.store
+	if store == nil {
+		if cfg.passphrase != "" {
+			boltStore, err := NewBoltStore(defaultStorePath, cfg.passphrase)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open default credential store: %v", err)
+			}
+			store = boltStore
+		} else {
+			store = newMemoryStore()
+		}
+	}
+
 	return &Wallet{
-		Credentials: []Credential{},
+		store:     store,
+		keyCache:  cfg.keyCache,
+		artifacts: map[string][]byte{},
 	}, nil
 }
 
-// Creates a new VC
-func (w *Wallet) IssueCredential(pk groth16.ProvingKey, vk groth16.VerifyingKey, r1cs constraint.ConstraintSystem, subject map[string]interface{}) (Credential, *AgeCheckCircuit, error) {
+// SaveArtifact persists a proving key, verifying key or constraint system
+// under name via the wallet's Store.
+func (w *Wallet) SaveArtifact(name string, artifact io.WriterTo) error {
+	if err := w.store.PutArtifact(name, artifact); err != nil {
+		return err
+	}
+
+	if w.keyCache {
+		var buf bytes.Buffer
+		if _, err := artifact.WriteTo(&buf); err == nil {
+			w.artifactMu.Lock()
+			w.artifacts[name] = buf.Bytes()
+			w.artifactMu.Unlock()
+		}
+	}
+	return nil
+}
+
+// LoadArtifact decodes a proving key, verifying key or constraint system
+// previously saved under name via SaveArtifact.
+func (w *Wallet) LoadArtifact(name string, artifact io.ReaderFrom) error {
+	if w.keyCache {
+		w.artifactMu.RLock()
+		cached, ok := w.artifacts[name]
+		w.artifactMu.RUnlock()
+		if ok {
+			_, err := artifact.ReadFrom(bytes.NewReader(cached))
+			return err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := w.store.GetArtifact(name, &buf); err != nil {
+		return err
+	}
+	if w.keyCache {
+		w.artifactMu.Lock()
+		w.artifacts[name] = append([]byte(nil), buf.Bytes()...)
+		w.artifactMu.Unlock()
+	}
+
+	_, err := artifact.ReadFrom(bytes.NewReader(buf.Bytes()))
+	return err
+}
+
+// Creates a new VC. iss is the issuer whose current status list the
+// credential's non-revocation proof is built against; issuance fails if iss
+// considers the freshly minted credential ID already revoked, which can
+// only happen if that ID was reused.
+func (w *Wallet) IssueCredential(pk groth16.ProvingKey, vk groth16.VerifyingKey, r1cs constraint.ConstraintSystem, iss *issuer.Issuer, subject map[string]interface{}) (Credential, *AgeCheckCircuit, error) {
 	// Extract birth year from subject
 	birthYearFloat, ok := subject["birthDate"].(string)
 	if !ok {
@@ -55,36 +173,238 @@ func (w *Wallet) IssueCredential(pk groth16.ProvingKey, vk groth16.VerifyingKey,
 	// Use the current year dynamically
 	currentYear := time.Now().Year()
 
+	credentialID := fmt.Sprintf("urn:uuid:%d", time.Now().UnixNano())
+	revocation, err := buildRevocationWitness(iss, credentialID)
+	if err != nil {
+		return Credential{}, nil, fmt.Errorf("failed to build non-revocation proof: %v", err)
+	}
+
 	// Run the ZKP proof generation
-	zkpProof, circuit, err := generateZKProof(pk, r1cs, birthYear, currentYear)
+	zkpProof, circuit, err := generateZKProof(pk, r1cs, birthYear, currentYear, revocation)
 	if err != nil {
 		return Credential{}, nil, fmt.Errorf("failed to generate ZKP proof: %v", err)
 	}
+	envelope, err := encodeGroth16Proof(*zkpProof)
+	if err != nil {
+		return Credential{}, nil, fmt.Errorf("failed to encode proof: %v", err)
+	}
 
 	cred := Credential{
-		ID:           fmt.Sprintf("urn:uuid:%d", time.Now().UnixNano()),
+		ID:           credentialID,
 		Type:         []string{"VerifiableCredential", "eIDASIdentityCredential"},
 		Issuer:       "did:example:issuer123",
 		IssuanceDate: time.Now(),
 		Subject:      subject,
-		Proof:        zkpProof,
+		Proof:        &envelope,
+		nbPublic:     r1cs.GetNbPublicVariables(),
+		nbSecret:     r1cs.GetNbSecretVariables(),
 	}
 
-	w.Credentials = append(w.Credentials, cred)
+	if err := w.store.Put(cred, nil); err != nil {
+		return Credential{}, nil, fmt.Errorf("failed to save credential: %v", err)
+	}
 
 	return cred, circuit, nil
 }
 
-func VerifyCredential(circuit *AgeCheckCircuit, cred *Credential, vk groth16.VerifyingKey) (bool, error) {
-	err := verifyZKProof(circuit, cred.Proof, vk)
+// IssuePolicyCredential creates a VC whose Subject attributes are bound by a
+// commitment under policy, so the holder can later call Present to disclose
+// only chosen attributes while proving predicates over the rest.
+func (w *Wallet) IssuePolicyCredential(policy []PresentationPolicy, subject map[string]interface{}) (Credential, error) {
+	root, attrSalts, err := attributeCommitment(policy, subject)
+	if err != nil {
+		return Credential{}, fmt.Errorf("failed to commit to subject attributes: %v", err)
+	}
+
+	cred := Credential{
+		ID:           fmt.Sprintf("urn:uuid:%d", time.Now().UnixNano()),
+		Type:         []string{"VerifiableCredential", "eIDASIdentityCredential"},
+		Issuer:       "did:example:issuer123",
+		IssuanceDate: time.Now(),
+		Subject:      subject,
+		Commitment:   root,
+	}
+
+	if err := w.store.Put(cred, attrSalts); err != nil {
+		return Credential{}, fmt.Errorf("failed to save credential: %v", err)
+	}
+
+	return cred, nil
+}
+
+// Present builds a selective-disclosure proof for cred against policy: the
+// witness reveals disclosed attributes as public inputs and keeps the rest
+// secret, proving only that they satisfy their predicate and are consistent
+// with cred.Commitment. backend/ccs must come from InitPolicyCircuit, so a
+// PlonkBackend-backed wallet reuses the same universal setup across every
+// policy instead of the per-shape Groth16 ceremony AgeCheckCircuit needs.
+func (w *Wallet) Present(cred *Credential, policy []PresentationPolicy, backend Backend, ccs constraint.ConstraintSystem, iss *issuer.Issuer) (*ProofEnvelope, *PolicyCircuit, error) {
+	if cred.Commitment == nil {
+		return nil, nil, fmt.Errorf("credential %s has no attribute commitment", cred.ID)
+	}
+	_, attrSalts, err := w.store.Get(cred.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load credential %s: %v", cred.ID, err)
+	}
+	if attrSalts == nil {
+		return nil, nil, fmt.Errorf("wallet has no salts for credential %s", cred.ID)
+	}
+	if _, err := NewCircuitBuilder(policy); err != nil {
+		return nil, nil, err
+	}
+	revocation, err := buildRevocationWitness(iss, cred.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build non-revocation proof: %v", err)
+	}
+
+	assignment := &PolicyCircuit{CommitmentRoot: cred.Commitment, Revocation: *revocation}
+	for i := 0; i < maxPolicyAttributes; i++ {
+		// Every slot needs a concrete value for every field regardless of
+		// which branch below is taken, or frontend.NewWitness fails on the
+		// untouched ones' nil zero value; padding and inactive branches are
+		// zeroed here and only overwritten where they apply.
+		assignment.Values[i] = big.NewInt(0)
+		assignment.Salts[i] = attrSalts[i]
+		assignment.Active[i] = 0
+		assignment.IsDisclosed[i] = 0
+		assignment.Disclosed[i] = big.NewInt(0)
+		assignment.IsRange[i] = 0
+		assignment.Min[i] = big.NewInt(0)
+		assignment.Max[i] = big.NewInt(0)
+		assignment.IsSet[i] = 0
+		for j := 0; j < maxSetValues; j++ {
+			assignment.Allowed[i][j] = big.NewInt(0)
+		}
+
+		if i >= len(policy) {
+			continue
+		}
+
+		p := policy[i]
+		value, err := attributeAsBigInt(cred.Subject, p.Attribute)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		assignment.Values[i] = value
+		assignment.Active[i] = 1
+
+		if p.Disclosed {
+			assignment.IsDisclosed[i] = 1
+			assignment.Disclosed[i] = value
+			continue
+		}
+
+		switch p.Predicate {
+		case PredicateRange:
+			assignment.IsRange[i] = 1
+			assignment.Min[i] = p.PublicParams["min"]
+			assignment.Max[i] = p.PublicParams["max"]
+		case PredicateSetMembership:
+			allowed, ok := p.PublicParams["allowed"].([]int64)
+			if !ok || len(allowed) == 0 {
+				return nil, nil, fmt.Errorf("set-membership predicate on %q needs a non-empty PublicParams[\"allowed\"]", p.Attribute)
+			}
+			if len(allowed) > maxSetValues {
+				return nil, nil, fmt.Errorf("set-membership predicate on %q has %d allowed values, max is %d", p.Attribute, len(allowed), maxSetValues)
+			}
+			assignment.IsSet[i] = 1
+			for j := 0; j < maxSetValues; j++ {
+				if j < len(allowed) {
+					assignment.Allowed[i][j] = allowed[j]
+				} else {
+					assignment.Allowed[i][j] = allowed[len(allowed)-1]
+				}
+			}
+		default:
+			return nil, nil, fmt.Errorf("attribute %q is hidden but has no predicate", p.Attribute)
+		}
+	}
+
+	witness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create witness: %v", err)
+	}
+
+	envelope, err := backend.Prove(ccs, witness)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate presentation proof: %v", err)
+	}
+
+	return &envelope, assignment, nil
+}
+
+// checkNonRevocationAgainstStatusList rejects a proof or presentation whose
+// embedded revocation witness doesn't tie back to an issuer-published status
+// list: statusList must be validly signed (and fresh, see
+// issuer.VerifyStatusList) by iss, and provedRoot — the StatusListRoot the
+// witness was built against — must match it exactly. Both AgeCheckCircuit
+// and PolicyCircuit embed a revocationWitness that the holder supplies
+// themselves when building a witness, so without this check a holder could
+// fabricate a self-consistent Merkle tree in which their credential was
+// never revoked.
+func checkNonRevocationAgainstStatusList(provedRoot frontend.Variable, statusList issuer.StatusListCredential, iss *issuer.Issuer) error {
+	if !issuer.VerifyStatusList(statusList, iss.PublicKey()) {
+		return fmt.Errorf("status list signature is invalid or expired")
+	}
+	root, ok := provedRoot.(*big.Int)
+	if !ok || root.Cmp(statusList.Root) != 0 {
+		return fmt.Errorf("proof was built against a stale status list root")
+	}
+	return nil
+}
+
+// VerifyPresentation checks a proof produced by Present against the public
+// inputs baked into circuit (commitment root, disclosed values, predicate
+// parameters) and, via statusList/iss, that the presentation's
+// non-revocation witness is built against the issuer's currently published
+// revocation root. backend must be Setup against the same circuit shape
+// Present proved against (see InitPolicyCircuit), Groth16 or PLONK.
+func VerifyPresentation(circuit *PolicyCircuit, envelope *ProofEnvelope, backend Backend, statusList issuer.StatusListCredential, iss *issuer.Issuer) (bool, error) {
+	if err := checkNonRevocationAgainstStatusList(circuit.Revocation.StatusListRoot, statusList, iss); err != nil {
+		return false, err
+	}
+
+	witness, err := frontend.NewWitness(circuit, ecc.BN254.ScalarField())
+	if err != nil {
+		return false, fmt.Errorf("failed to create witness: %v", err)
+	}
+
+	publicWitness, err := witness.Public()
+	if err != nil {
+		return false, fmt.Errorf("failed to get public witness: %v", err)
+	}
+
+	if err := backend.Verify(*envelope, publicWitness); err != nil {
+		return false, fmt.Errorf("presentation verification failed: %v", err)
+	}
+
+	return true, nil
+}
+
+// VerifyCredential checks cred's ZKP proof, including its non-revocation
+// gadget, against statusList.
+func VerifyCredential(circuit *AgeCheckCircuit, cred *Credential, vk groth16.VerifyingKey, statusList issuer.StatusListCredential, iss *issuer.Issuer) (bool, error) {
+	if err := checkNonRevocationAgainstStatusList(circuit.Revocation.StatusListRoot, statusList, iss); err != nil {
+		return false, err
+	}
+	if cred.Proof == nil {
+		return false, fmt.Errorf("credential has no proof")
+	}
+
+	proof, err := decodeGroth16Proof(*cred.Proof)
 	if err != nil {
+		return false, fmt.Errorf("failed to decode proof: %v", err)
+	}
+
+	if err := verifyZKProof(circuit, &proof, vk); err != nil {
 		return false, err
 	}
-	return true, err
+	return true, nil
 }
 
 // HTTP Handlers
-func issueCredential(pk groth16.ProvingKey, vk groth16.VerifyingKey, r1cs constraint.ConstraintSystem, w *Wallet) (*Credential, *AgeCheckCircuit) {
+func issueCredential(pk groth16.ProvingKey, vk groth16.VerifyingKey, r1cs constraint.ConstraintSystem, iss *issuer.Issuer, w *Wallet) (*Credential, *AgeCheckCircuit) {
 	subject := map[string]interface{}{
 		"id":          "did:example:user123",
 		"givenName":   "Adrien",
@@ -93,7 +413,7 @@ func issueCredential(pk groth16.ProvingKey, vk groth16.VerifyingKey, r1cs constr
 		"nationality": "FR",
 	}
 
-	cred, circuit, err := w.IssueCredential(pk, vk, r1cs, subject)
+	cred, circuit, err := w.IssueCredential(pk, vk, r1cs, iss, subject)
 	if err != nil {
 		fmt.Printf("Failed to issue credential: %v\n", err)
 		return nil, nil
@@ -103,8 +423,8 @@ func issueCredential(pk groth16.ProvingKey, vk groth16.VerifyingKey, r1cs constr
 	return &cred, circuit
 }
 
-func verifyCredential(c *Credential, circuit *AgeCheckCircuit, vk groth16.VerifyingKey) {
-	valid, err := VerifyCredential(circuit, c, vk)
+func verifyCredential(c *Credential, circuit *AgeCheckCircuit, vk groth16.VerifyingKey, statusList issuer.StatusListCredential, iss *issuer.Issuer) {
+	valid, err := VerifyCredential(circuit, c, vk, statusList, iss)
 	if err != nil {
 		fmt.Printf("Verification error: %v\n", err)
 		return
@@ -117,17 +437,33 @@ func verifyCredential(c *Credential, circuit *AgeCheckCircuit, vk groth16.Verify
 	}
 }
 
+// demoPassphrase seals main's default BoltDB-backed wallet, so the circuit
+// artifacts LoadOrInitCircuit persists actually survive a process restart
+// instead of living only in an in-memory Store.
+const demoPassphrase = "change-me"
+
 func main() {
-	wallet, err := NewWallet()
+	wallet, err := NewWallet(WithPassphrase(demoPassphrase))
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	pk, vk, r1cs, err := InitCircuit()
+	iss, err := issuer.New("did:example:issuer123")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pk, vk, r1cs, err := LoadOrInitCircuit(wallet)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cred, circuit := issueCredential(pk, vk, r1cs, iss, wallet)
+
+	statusList, err := iss.PublishStatusList()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	cred, circuit := issueCredential(pk, vk, r1cs, wallet)
-	verifyCredential(cred, circuit, vk)
+	verifyCredential(cred, circuit, vk, statusList, iss)
 }
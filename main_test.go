@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/AdrienFromToulouse/eudi/issuer"
+)
+
+// TestPresentAndVerifyPresentation exercises the selective-disclosure path
+// end to end: issue a policy-bound credential, build a presentation that
+// discloses one attribute and proves a range predicate over another, then
+// verify it. It guards against Present leaving any PolicyCircuit witness
+// field unset, which previously made frontend.NewWitness fail for every
+// policy shorter than maxPolicyAttributes.
+func TestPresentAndVerifyPresentation(t *testing.T) {
+	wallet, err := NewWallet()
+	if err != nil {
+		t.Fatalf("NewWallet: %v", err)
+	}
+
+	iss, err := issuer.New("did:example:test-issuer")
+	if err != nil {
+		t.Fatalf("issuer.New: %v", err)
+	}
+
+	policy := []PresentationPolicy{
+		{Attribute: "givenName", Disclosed: true},
+		{
+			Attribute:    "birthYear",
+			Predicate:    PredicateRange,
+			PublicParams: map[string]interface{}{"min": int64(1900), "max": int64(2010)},
+		},
+	}
+	subject := map[string]interface{}{
+		"givenName": "Adrien",
+		"birthYear": "1984",
+	}
+
+	cred, err := wallet.IssuePolicyCredential(policy, subject)
+	if err != nil {
+		t.Fatalf("IssuePolicyCredential: %v", err)
+	}
+
+	backend := NewGroth16Backend()
+	ccs, err := InitPolicyCircuit(policy, backend)
+	if err != nil {
+		t.Fatalf("InitPolicyCircuit: %v", err)
+	}
+
+	envelope, circuit, err := wallet.Present(&cred, policy, backend, ccs, iss)
+	if err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+
+	statusList, err := iss.PublishStatusList()
+	if err != nil {
+		t.Fatalf("PublishStatusList: %v", err)
+	}
+
+	ok, err := VerifyPresentation(circuit, envelope, backend, statusList, iss)
+	if err != nil {
+		t.Fatalf("VerifyPresentation: %v", err)
+	}
+	if !ok {
+		t.Fatal("VerifyPresentation reported an invalid proof")
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bn254mimc "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+)
+
+// maxPolicyAttributes bounds how many PresentationPolicy entries a
+// CircuitBuilder will compose into a single PolicyCircuit. gnark circuits
+// need a fixed shape at compile time, so policies with fewer entries pad the
+// remaining slots with inactive leaves.
+const maxPolicyAttributes = 8
+
+// maxSetValues bounds how many values a PredicateSetMembership entry's
+// PublicParams["allowed"] may hold. Like maxPolicyAttributes, this is a
+// gnark circuit-shape constant: allow-lists shorter than maxSetValues pad by
+// repeating their last element, which a legitimate value matches anyway.
+const maxSetValues = 8
+
+// PredicateKind is the kind of statement a PresentationPolicy entry proves
+// about a hidden Credential.Subject attribute without revealing its value.
+type PredicateKind string
+
+const (
+	// PredicateNone means the attribute is disclosed in the clear and no
+	// predicate is proven over it.
+	PredicateNone PredicateKind = ""
+	// PredicateRange proves Min <= attribute <= Max.
+	PredicateRange PredicateKind = "range"
+	// PredicateSetMembership proves the attribute equals one of a small set
+	// of public allowed values (e.g. nationality in {FR,DE,IT}).
+	PredicateSetMembership PredicateKind = "set"
+)
+
+// PresentationPolicy describes what a holder must disclose or prove about a
+// single Credential.Subject attribute when building a presentation. A full
+// presentation policy is a []PresentationPolicy, at most maxPolicyAttributes
+// long, covering every attribute the verifier cares about.
+type PresentationPolicy struct {
+	Attribute    string
+	Disclosed    bool
+	Predicate    PredicateKind
+	PublicParams map[string]interface{} // "min"/"max" for PredicateRange, "allowed" ([]int64, up to maxSetValues) for PredicateSetMembership
+}
+
+// CircuitBuilder dynamically composes a PolicyCircuit from a
+// PresentationPolicy, replacing the hard-coded AgeCheckCircuit for wallets
+// that need selective disclosure with arbitrary predicates.
+type CircuitBuilder struct {
+	Policy []PresentationPolicy
+}
+
+// NewCircuitBuilder returns a CircuitBuilder for the given policy.
+func NewCircuitBuilder(policy []PresentationPolicy) (*CircuitBuilder, error) {
+	if len(policy) == 0 {
+		return nil, fmt.Errorf("presentation policy must have at least one attribute")
+	}
+	if len(policy) > maxPolicyAttributes {
+		return nil, fmt.Errorf("presentation policy has %d attributes, max is %d", len(policy), maxPolicyAttributes)
+	}
+	return &CircuitBuilder{Policy: policy}, nil
+}
+
+// PolicyCircuit is the gnark circuit assembled by CircuitBuilder. Every VC
+// attribute is committed to at issuance via CommitmentRoot (a MiMC hash over
+// value||salt pairs); presenting against a policy proves, per slot, either
+// equality with a disclosed public value or a range/set predicate over a
+// hidden one, while re-deriving CommitmentRoot from the secret witness.
+type PolicyCircuit struct {
+	CommitmentRoot frontend.Variable `gnark:",public"`
+
+	Active      [maxPolicyAttributes]frontend.Variable               `gnark:",public"` // 1 for slots used by this policy, 0 for padding
+	IsDisclosed [maxPolicyAttributes]frontend.Variable               `gnark:",public"`
+	IsRange     [maxPolicyAttributes]frontend.Variable               `gnark:",public"`
+	Min         [maxPolicyAttributes]frontend.Variable               `gnark:",public"`
+	Max         [maxPolicyAttributes]frontend.Variable               `gnark:",public"`
+	IsSet       [maxPolicyAttributes]frontend.Variable               `gnark:",public"`
+	Allowed     [maxPolicyAttributes][maxSetValues]frontend.Variable `gnark:",public"` // PublicParams["allowed"], padded to maxSetValues by repeating its last element
+	Disclosed   [maxPolicyAttributes]frontend.Variable               `gnark:",public"` // disclosed value, 0 for hidden slots
+
+	Values [maxPolicyAttributes]frontend.Variable `gnark:",secret"`
+	Salts  [maxPolicyAttributes]frontend.Variable `gnark:",secret"`
+
+	// Revocation proves the credential this presentation is built from
+	// hasn't been added to the issuer's published revocation tree.
+	Revocation revocationWitness
+}
+
+// Define declares the constraints for a policy-built presentation: the
+// commitment must open to the secret (value, salt) pairs, disclosed slots
+// must match their public value, and hidden slots must satisfy their range
+// or set-membership predicate. Inactive slots are excluded from every check.
+func (c *PolicyCircuit) Define(api frontend.API) error {
+	hasher, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < maxPolicyAttributes; i++ {
+		hasher.Write(c.Values[i], c.Salts[i], c.Active[i])
+	}
+	api.AssertIsEqual(hasher.Sum(), c.CommitmentRoot)
+
+	for i := 0; i < maxPolicyAttributes; i++ {
+		active := c.Active[i]
+
+		// Disclosed slots: value must equal the public attribute, gated by active.
+		discloseOK := api.IsZero(api.Sub(c.Values[i], c.Disclosed[i]))
+		discloseFails := api.Mul(api.Mul(active, c.IsDisclosed[i]), api.Sub(1, discloseOK))
+		api.AssertIsEqual(discloseFails, 0)
+
+		// Range slots: Min <= value <= Max, gated by active and not-disclosed.
+		// api.Cmp returns -1 exactly when its first argument is strictly less
+		// than its second, so below-Min and above-Max are each witnessed by a
+		// Cmp result of -1; assert that violation indicator is 0 when gated.
+		hidden := api.Sub(1, c.IsDisclosed[i])
+		rangeGate := api.Mul(api.Mul(active, hidden), c.IsRange[i])
+		geMin := api.Cmp(c.Values[i], c.Min[i])
+		leMax := api.Cmp(c.Max[i], c.Values[i])
+		api.AssertIsEqual(api.Mul(rangeGate, api.IsZero(api.Add(geMin, 1))), 0) // geMin == -1: value < Min
+		api.AssertIsEqual(api.Mul(rangeGate, api.IsZero(api.Add(leMax, 1))), 0) // leMax == -1: value > Max
+
+		// Set-membership slots: value must equal one of the (padded)
+		// maxSetValues allowed values, gated by active, not-disclosed and
+		// IsSet. The product of (value - allowed[j]) over every slot is zero
+		// iff value matches at least one of them.
+		setGate := api.Mul(api.Mul(active, hidden), c.IsSet[i])
+		diffProduct := frontend.Variable(1)
+		for j := 0; j < maxSetValues; j++ {
+			diffProduct = api.Mul(diffProduct, api.Sub(c.Values[i], c.Allowed[i][j]))
+		}
+		setOK := api.IsZero(diffProduct)
+		api.AssertIsEqual(api.Mul(setGate, api.Sub(1, setOK)), 0)
+	}
+
+	return assertNonRevoked(api, &c.Revocation)
+}
+
+// builderForScheme returns the frontend.NewBuilder a circuit must be
+// compiled with before backend.Setup: Groth16Backend needs the R1CS
+// r1csBuilder produces, PlonkBackend needs the sparse R1CS scsBuilder
+// produces (see CompileForPlonk).
+func builderForScheme(scheme Scheme) (frontend.NewBuilder, error) {
+	switch scheme {
+	case SchemeGroth16BN254:
+		return r1csBuilder, nil
+	case SchemePlonkBN254:
+		return scsBuilder, nil
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q", scheme)
+	}
+}
+
+// InitPolicyCircuit compiles PolicyCircuit's fixed shape (maxPolicyAttributes,
+// unlike InitCircuit's per-circuit AgeCheckCircuit shape) and runs backend's
+// trusted setup over it. Since the shape depends only on maxPolicyAttributes
+// and not on policy's actual length, the same backend/keys can be reused
+// across every policy that fits within it — in particular, a PlonkBackend
+// set up once here serves every policy without a fresh per-shape ceremony.
+func InitPolicyCircuit(policy []PresentationPolicy, backend Backend) (constraint.ConstraintSystem, error) {
+	if _, err := NewCircuitBuilder(policy); err != nil {
+		return nil, err
+	}
+
+	builder, err := builderForScheme(backend.Scheme())
+	if err != nil {
+		return nil, err
+	}
+
+	var circuit PolicyCircuit
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), builder, &circuit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile policy circuit: %v", err)
+	}
+
+	if err := backend.Setup(ccs); err != nil {
+		return nil, fmt.Errorf("failed to setup policy circuit: %v", err)
+	}
+
+	return ccs, nil
+}
+
+// attributeCommitment hashes subject's attributes (in policy order) together
+// with per-attribute salts into the MiMC commitment stored on the
+// Credential at issuance time. It returns the root plus one fresh salt per
+// attribute, which the holder must retain to later build presentations.
+func attributeCommitment(policy []PresentationPolicy, subject map[string]interface{}) (*big.Int, []*big.Int, error) {
+	h := bn254mimc.NewMiMC()
+	salts := make([]*big.Int, maxPolicyAttributes)
+
+	for i := 0; i < maxPolicyAttributes; i++ {
+		value := big.NewInt(0)
+		if i < len(policy) {
+			v, err := attributeAsBigInt(subject, policy[i].Attribute)
+			if err != nil {
+				return nil, nil, err
+			}
+			value = v
+		}
+		salt, err := randomFieldElement()
+		if err != nil {
+			return nil, nil, err
+		}
+		salts[i] = salt
+
+		active := big.NewInt(0)
+		if i < len(policy) {
+			active = big.NewInt(1)
+		}
+
+		h.Write(fieldBytes(value))
+		h.Write(fieldBytes(salt))
+		h.Write(fieldBytes(active))
+	}
+
+	return new(big.Int).SetBytes(h.Sum(nil)), salts, nil
+}
+
+// fieldBytes returns v's big-endian encoding as a fixed-width, BN254
+// scalar-field-sized block. big.Int.Bytes strips leading zero bytes (and
+// returns an empty slice for 0), which the MiMC hash.Hash.Write silently
+// drops instead of hashing a zero block; attributeCommitment must hash the
+// same fixed-width blocks the in-circuit mimc gadget does, or its off-circuit
+// root desyncs from PolicyCircuit.Define's re-derivation of it.
+func fieldBytes(v *big.Int) []byte {
+	buf := make([]byte, bn254mimc.BlockSize)
+	v.FillBytes(buf)
+	return buf
+}
+
+// maxEncodableStringLen bounds attributeAsBigInt's raw byte encoding of a
+// non-numeric string attribute: the BN254 scalar field holds about 31.75
+// bytes, so anything longer wouldn't round-trip as a single field element.
+const maxEncodableStringLen = 31
+
+// attributeAsBigInt coerces a Credential.Subject field into the integer form
+// gnark witnesses deal in. A string is first tried as a base-10 integer
+// (e.g. a birth year); if it isn't one, it's encoded as the big-endian
+// integer of its raw bytes (e.g. "FR" in a nationality predicate), which is
+// deterministic so the same string always commits to and is compared
+// against the same field element. Anything else must already be a whole
+// number.
+func attributeAsBigInt(subject map[string]interface{}, attribute string) (*big.Int, error) {
+	raw, ok := subject[attribute]
+	if !ok {
+		return nil, fmt.Errorf("subject has no attribute %q", attribute)
+	}
+
+	switch v := raw.(type) {
+	case string:
+		if n, ok := new(big.Int).SetString(v, 10); ok {
+			return n, nil
+		}
+		if len(v) > maxEncodableStringLen {
+			return nil, fmt.Errorf("attribute %q is too long to encode as a field element: %q", attribute, v)
+		}
+		return new(big.Int).SetBytes([]byte(v)), nil
+	case int:
+		return big.NewInt(int64(v)), nil
+	case int64:
+		return big.NewInt(v), nil
+	case float64:
+		return big.NewInt(int64(v)), nil
+	default:
+		return nil, fmt.Errorf("attribute %q has unsupported type %T", attribute, raw)
+	}
+}
+
+// randomFieldElement returns a uniformly random element of the BN254 scalar
+// field, used to blind each attribute leaf in the commitment.
+func randomFieldElement() (*big.Int, error) {
+	return rand.Int(rand.Reader, ecc.BN254.ScalarField())
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	stdgroth16 "github.com/consensys/gnark/std/recursion/groth16"
+)
+
+// RecursiveCredentialCircuit lets an issuer attest "holder possesses a valid
+// parent credential" without re-proving the parent circuit's statement from
+// scratch. It embeds an in-circuit Groth16 verifier for the parent proof
+// (emulated over BN254's own scalar field, since BN254 isn't 2-cycle
+// friendly); it re-attests the parent proof only and asserts no additional
+// predicate of its own. A derived circuit that needs to prove something
+// beyond the parent statement should add a witness field for it and
+// constrain that field in Define, the way AgeCheckCircuit/PolicyCircuit
+// constrain their own secret inputs.
+type RecursiveCredentialCircuit struct {
+	// ParentProof/ParentVK/ParentWitness are the emulated representation of
+	// the parent credential's Groth16 proof, verifying key and public
+	// inputs, checked via sw_bn254's emulated pairing and MSM.
+	ParentProof   stdgroth16.Proof[sw_bn254.G1Affine, sw_bn254.G2Affine]
+	ParentVK      stdgroth16.VerifyingKey[sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl]
+	ParentWitness stdgroth16.Witness[sw_bn254.ScalarField]
+}
+
+// Define verifies the embedded parent proof in-circuit.
+func (c *RecursiveCredentialCircuit) Define(api frontend.API) error {
+	verifier, err := stdgroth16.NewVerifier[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](api)
+	if err != nil {
+		return fmt.Errorf("failed to build in-circuit Groth16 verifier: %v", err)
+	}
+	if err := verifier.AssertProof(c.ParentVK, c.ParentProof, c.ParentWitness); err != nil {
+		return fmt.Errorf("parent credential proof is invalid: %v", err)
+	}
+
+	return nil
+}
+
+// InitRecursiveCircuit compiles and runs trusted setup for
+// RecursiveCredentialCircuit. innerCcs is the parent credential circuit's own
+// compiled constraint system (e.g. from InitCircuit): the emulated
+// ParentProof/ParentVK/ParentWitness fields size themselves off its public
+// input and commitment counts, not its internal shape, so the same recursive
+// keys serve every parent generation compiled against the same circuit.
+func InitRecursiveCircuit(innerCcs constraint.ConstraintSystem) (groth16.ProvingKey, groth16.VerifyingKey, constraint.ConstraintSystem, error) {
+	circuit := RecursiveCredentialCircuit{
+		ParentProof:   stdgroth16.PlaceholderProof[sw_bn254.G1Affine, sw_bn254.G2Affine](innerCcs),
+		ParentVK:      stdgroth16.PlaceholderVerifyingKey[sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](innerCcs),
+		ParentWitness: stdgroth16.PlaceholderWitness[sw_bn254.ScalarField](innerCcs),
+	}
+
+	r1csCS, err := frontend.Compile(ecc.BN254.ScalarField(), r1csBuilder, &circuit)
+	if err != nil {
+		fmt.Printf("Failed to compile recursive circuit: %v\n", err)
+		return nil, nil, nil, err
+	}
+
+	pk, vk, err := groth16.Setup(r1csCS)
+	if err != nil {
+		fmt.Printf("Failed to setup recursive circuit: %v\n", err)
+		return nil, nil, nil, err
+	}
+
+	return pk, vk, r1csCS, nil
+}
+
+// IssueDerivedCredential builds a RecursiveCredentialCircuit witness from a
+// parent credential's proof, VK and public circuit (the witness holding its
+// public inputs), proves it, and wraps the result as a new Credential
+// chained to parentCred without the issuer re-verifying parentCred's own
+// witness.
+func (w *Wallet) IssueDerivedCredential(parentCred *Credential, parentCircuit *AgeCheckCircuit, parentVK groth16.VerifyingKey, pk groth16.ProvingKey, r1cs constraint.ConstraintSystem) (Credential, *RecursiveCredentialCircuit, error) {
+	if parentCred.Proof == nil {
+		return Credential{}, nil, fmt.Errorf("parent credential %s has no proof to recurse over", parentCred.ID)
+	}
+
+	decodedParentProof, err := decodeGroth16Proof(*parentCred.Proof)
+	if err != nil {
+		return Credential{}, nil, fmt.Errorf("failed to decode parent proof: %v", err)
+	}
+	parentProof, err := stdgroth16.ValueOfProof[sw_bn254.G1Affine, sw_bn254.G2Affine](decodedParentProof)
+	if err != nil {
+		return Credential{}, nil, fmt.Errorf("failed to lift parent proof into circuit values: %v", err)
+	}
+	parentVKValue, err := stdgroth16.ValueOfVerifyingKey[sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](parentVK)
+	if err != nil {
+		return Credential{}, nil, fmt.Errorf("failed to lift parent verifying key into circuit values: %v", err)
+	}
+	parentFullWitness, err := frontend.NewWitness(parentCircuit, ecc.BN254.ScalarField())
+	if err != nil {
+		return Credential{}, nil, fmt.Errorf("failed to build parent witness: %v", err)
+	}
+	parentPublicWitness, err := parentFullWitness.Public()
+	if err != nil {
+		return Credential{}, nil, fmt.Errorf("failed to get parent public witness: %v", err)
+	}
+	parentWitness, err := stdgroth16.ValueOfWitness[sw_bn254.ScalarField](parentPublicWitness)
+	if err != nil {
+		return Credential{}, nil, fmt.Errorf("failed to lift parent public witness into circuit values: %v", err)
+	}
+
+	assignment := &RecursiveCredentialCircuit{
+		ParentProof:   parentProof,
+		ParentVK:      parentVKValue,
+		ParentWitness: parentWitness,
+	}
+
+	witness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		return Credential{}, nil, fmt.Errorf("failed to create witness: %v", err)
+	}
+
+	proof, err := groth16.Prove(r1cs, pk, witness)
+	if err != nil {
+		return Credential{}, nil, fmt.Errorf("failed to generate derived proof: %v", err)
+	}
+	envelope, err := encodeGroth16Proof(proof)
+	if err != nil {
+		return Credential{}, nil, fmt.Errorf("failed to encode derived proof: %v", err)
+	}
+
+	derived := Credential{
+		ID:           fmt.Sprintf("urn:uuid:%d", time.Now().UnixNano()),
+		Type:         []string{"VerifiableCredential", "eIDASIdentityCredential", "DerivedCredential"},
+		Issuer:       "did:example:issuer123",
+		IssuanceDate: time.Now(),
+		Subject:      parentCred.Subject,
+		Proof:        &envelope,
+		nbPublic:     r1cs.GetNbPublicVariables(),
+		nbSecret:     r1cs.GetNbSecretVariables(),
+	}
+	if err := w.store.Put(derived, nil); err != nil {
+		return Credential{}, nil, fmt.Errorf("failed to save derived credential: %v", err)
+	}
+
+	return derived, assignment, nil
+}
+
+// VerifyDerivedCredential checks a proof produced by IssueDerivedCredential
+// against circuit's public inputs (the parent proof's verifying key and
+// public witness) and the recursive circuit's own verifying key, the same
+// way VerifyCredential checks an AgeCheckCircuit proof.
+func VerifyDerivedCredential(cred *Credential, circuit *RecursiveCredentialCircuit, vk groth16.VerifyingKey) (bool, error) {
+	if cred.Proof == nil {
+		return false, fmt.Errorf("credential %s has no proof", cred.ID)
+	}
+
+	proof, err := decodeGroth16Proof(*cred.Proof)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode proof: %v", err)
+	}
+
+	witness, err := frontend.NewWitness(circuit, ecc.BN254.ScalarField())
+	if err != nil {
+		return false, fmt.Errorf("failed to create witness: %v", err)
+	}
+	publicWitness, err := witness.Public()
+	if err != nil {
+		return false, fmt.Errorf("failed to get public witness: %v", err)
+	}
+
+	if err := groth16.Verify(proof, vk, publicWitness); err != nil {
+		return false, fmt.Errorf("derived credential verification failed: %v", err)
+	}
+	return true, nil
+}
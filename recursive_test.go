@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/AdrienFromToulouse/eudi/issuer"
+)
+
+// TestIssueAndVerifyDerivedCredential exercises the recursive verifier end
+// to end: issue a parent AgeCheckCircuit credential, fold its proof into a
+// RecursiveCredentialCircuit derived credential, then verify the derived
+// proof. It guards against InitRecursiveCircuit compiling a circuit whose
+// emulated ParentProof/ParentVK/ParentWitness fields are sized from a bare
+// zero value instead of the parent circuit's own compiled shape.
+func TestIssueAndVerifyDerivedCredential(t *testing.T) {
+	wallet, err := NewWallet()
+	if err != nil {
+		t.Fatalf("NewWallet: %v", err)
+	}
+
+	iss, err := issuer.New("did:example:test-issuer")
+	if err != nil {
+		t.Fatalf("issuer.New: %v", err)
+	}
+
+	pk, vk, ccs, err := InitCircuit()
+	if err != nil {
+		t.Fatalf("InitCircuit: %v", err)
+	}
+
+	subject := map[string]interface{}{"birthDate": "1984-01-01"}
+	parentCred, parentCircuit, err := wallet.IssueCredential(pk, vk, ccs, iss, subject)
+	if err != nil {
+		t.Fatalf("IssueCredential: %v", err)
+	}
+
+	recPK, recVK, recCCS, err := InitRecursiveCircuit(ccs)
+	if err != nil {
+		t.Fatalf("InitRecursiveCircuit: %v", err)
+	}
+
+	derived, derivedCircuit, err := wallet.IssueDerivedCredential(&parentCred, parentCircuit, vk, recPK, recCCS)
+	if err != nil {
+		t.Fatalf("IssueDerivedCredential: %v", err)
+	}
+
+	ok, err := VerifyDerivedCredential(&derived, derivedCircuit, recVK)
+	if err != nil {
+		t.Fatalf("VerifyDerivedCredential: %v", err)
+	}
+	if !ok {
+		t.Fatal("VerifyDerivedCredential reported an invalid proof")
+	}
+}
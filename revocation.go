@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/math/bits"
+
+	"github.com/AdrienFromToulouse/eudi/issuer"
+)
+
+// revocationWitness is embedded into any circuit that must prove its
+// credential hasn't been revoked: the holder supplies the two leaves of the
+// issuer's published Merkle tree that the credential ID's hash sorts
+// between, along with their inclusion paths, and the circuit checks both
+// paths against the same StatusListRoot and that the ID falls strictly
+// between them.
+type revocationWitness struct {
+	StatusListRoot frontend.Variable `gnark:",public"`
+	CredentialID   frontend.Variable `gnark:",secret"`
+
+	IndexLow    frontend.Variable                   `gnark:",secret"`
+	LowLeaf     frontend.Variable                   `gnark:",secret"`
+	LowSiblings [issuer.TreeDepth]frontend.Variable `gnark:",secret"`
+
+	IndexHigh    frontend.Variable                   `gnark:",secret"`
+	HighLeaf     frontend.Variable                   `gnark:",secret"`
+	HighSiblings [issuer.TreeDepth]frontend.Variable `gnark:",secret"`
+}
+
+// assertNonRevoked constrains w's witness to prove its CredentialID is
+// absent from the revocation tree rooted at w.StatusListRoot.
+func assertNonRevoked(api frontend.API, w *revocationWitness) error {
+	// CredentialID is already issuer.HashCredentialID(id) (the same MiMC
+	// hash the revocation tree's leaves are built from), not the raw ID, so
+	// it's compared against the leaves directly rather than re-hashed here.
+	idHash := w.CredentialID
+
+	// The high leaf must be the one immediately after the low leaf, so
+	// there's no revoked entry the witness could be hiding between them.
+	api.AssertIsEqual(api.Add(w.IndexLow, 1), w.IndexHigh)
+
+	if err := assertMerklePath(api, w.LowLeaf, w.IndexLow, w.LowSiblings[:], w.StatusListRoot); err != nil {
+		return err
+	}
+	if err := assertMerklePath(api, w.HighLeaf, w.IndexHigh, w.HighSiblings[:], w.StatusListRoot); err != nil {
+		return err
+	}
+
+	api.AssertIsEqual(api.Cmp(idHash, w.LowLeaf), 1)
+	api.AssertIsEqual(api.Cmp(w.HighLeaf, idHash), 1)
+
+	return nil
+}
+
+// assertMerklePath asserts that leaf, walked up via siblings according to
+// index's bits, recomputes root.
+func assertMerklePath(api frontend.API, leaf, index frontend.Variable, siblings []frontend.Variable, root frontend.Variable) error {
+	idxBits := bits.ToBinary(api, index, bits.WithNbDigits(issuer.TreeDepth))
+
+	cur := leaf
+	for d := 0; d < issuer.TreeDepth; d++ {
+		hasher, err := mimc.NewMiMC(api)
+		if err != nil {
+			return err
+		}
+		left := api.Select(idxBits[d], siblings[d], cur)
+		right := api.Select(idxBits[d], cur, siblings[d])
+		hasher.Write(left, right)
+		cur = hasher.Sum()
+	}
+	api.AssertIsEqual(cur, root)
+
+	return nil
+}
+
+// buildRevocationWitness fills a revocationWitness assignment for
+// credentialID against iss's currently published status list.
+func buildRevocationWitness(iss *issuer.Issuer, credentialID string) (*revocationWitness, error) {
+	low, high, err := iss.NonRevocationProof(credentialID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build non-revocation proof: %v", err)
+	}
+
+	w := &revocationWitness{
+		StatusListRoot: iss.Root(),
+		CredentialID:   issuer.HashCredentialID(credentialID),
+		IndexLow:       big.NewInt(int64(low.Index)),
+		LowLeaf:        low.Leaf,
+		IndexHigh:      big.NewInt(int64(high.Index)),
+		HighLeaf:       high.Leaf,
+	}
+	for d := 0; d < issuer.TreeDepth; d++ {
+		w.LowSiblings[d] = low.Siblings[d]
+		w.HighSiblings[d] = high.Siblings[d]
+	}
+
+	return w, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/AdrienFromToulouse/eudi/issuer"
+)
+
+// TestPresentRejectsRevokedCredential exercises the negative path
+// chunk0-1/chunk0-4 exist to enforce: once a credential's ID is revoked,
+// neither building a fresh presentation over it nor replaying an older
+// presentation against the issuer's current status list succeeds.
+func TestPresentRejectsRevokedCredential(t *testing.T) {
+	wallet, err := NewWallet()
+	if err != nil {
+		t.Fatalf("NewWallet: %v", err)
+	}
+
+	iss, err := issuer.New("did:example:test-issuer")
+	if err != nil {
+		t.Fatalf("issuer.New: %v", err)
+	}
+
+	policy := []PresentationPolicy{
+		{Attribute: "givenName", Disclosed: true},
+	}
+	subject := map[string]interface{}{"givenName": "Adrien"}
+
+	cred, err := wallet.IssuePolicyCredential(policy, subject)
+	if err != nil {
+		t.Fatalf("IssuePolicyCredential: %v", err)
+	}
+
+	backend := NewGroth16Backend()
+	ccs, err := InitPolicyCircuit(policy, backend)
+	if err != nil {
+		t.Fatalf("InitPolicyCircuit: %v", err)
+	}
+
+	envelope, circuit, err := wallet.Present(&cred, policy, backend, ccs, iss)
+	if err != nil {
+		t.Fatalf("Present before revocation: %v", err)
+	}
+
+	if err := iss.Revoke(cred.ID); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+
+	if _, _, err := wallet.Present(&cred, policy, backend, ccs, iss); err == nil {
+		t.Fatal("Present succeeded for a revoked credential, want an error")
+	}
+
+	currentStatusList, err := iss.PublishStatusList()
+	if err != nil {
+		t.Fatalf("PublishStatusList: %v", err)
+	}
+	if ok, err := VerifyPresentation(circuit, envelope, backend, currentStatusList, iss); err == nil || ok {
+		t.Fatalf("VerifyPresentation accepted a proof whose embedded root predates revocation (ok=%v, err=%v)", ok, err)
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// vcContext is the JSON-LD @context every Credential serializes with, per
+// the W3C Verifiable Credentials Data Model 2.0.
+var vcContext = []string{
+	"https://www.w3.org/ns/credentials/v2",
+	"https://www.w3.org/ns/credentials/examples/v2",
+}
+
+// cryptosuiteForScheme maps a ProofEnvelope's Scheme to the
+// DataIntegrityProof cryptosuite string a verifier should key off of.
+func cryptosuiteForScheme(scheme Scheme) (string, error) {
+	switch scheme {
+	case SchemeGroth16BN254:
+		return "groth16-bn254-2024", nil
+	case SchemePlonkBN254:
+		return "plonk-bn254-2024", nil
+	default:
+		return "", fmt.Errorf("unsupported scheme %q", scheme)
+	}
+}
+
+// schemeForCryptosuite is the inverse of cryptosuiteForScheme.
+func schemeForCryptosuite(cryptosuite string) (Scheme, error) {
+	switch cryptosuite {
+	case "groth16-bn254-2024":
+		return SchemeGroth16BN254, nil
+	case "plonk-bn254-2024":
+		return SchemePlonkBN254, nil
+	default:
+		return "", fmt.Errorf("unsupported cryptosuite %q", cryptosuite)
+	}
+}
+
+// DataIntegrityProof is the W3C Data Integrity representation of a
+// ProofEnvelope (https://www.w3.org/TR/vc-data-integrity/). proofValue is
+// multibase(base64url) of a small header (nbPublicVariables, nbSecretVariables,
+// matching the witness-package [nbPublic nbSecret][n|elements] layout)
+// followed by the envelope's raw proof bytes, so a verifier can check the
+// witness shape before attempting to verify; Cryptosuite identifies which
+// Backend produced those bytes.
+type DataIntegrityProof struct {
+	Type               string    `json:"type"`
+	Cryptosuite        string    `json:"cryptosuite"`
+	Created            time.Time `json:"created"`
+	VerificationMethod string    `json:"verificationMethod"`
+	ProofPurpose       string    `json:"proofPurpose"`
+	ProofValue         string    `json:"proofValue"`
+}
+
+// credentialJSON is the W3C VC Data Model 2.0 wire shape Credential
+// marshals to and from; Credential itself keeps Go-friendly fields
+// (*ProofEnvelope, unexported variable counts) that don't map 1:1 onto it.
+type credentialJSON struct {
+	Context      []string               `json:"@context"`
+	ID           string                 `json:"id"`
+	Type         []string               `json:"type"`
+	Issuer       string                 `json:"issuer"`
+	IssuanceDate time.Time              `json:"issuanceDate"`
+	Subject      map[string]interface{} `json:"credentialSubject"`
+	Commitment   *big.Int               `json:"commitment,omitempty"`
+	Proof        *DataIntegrityProof    `json:"proof,omitempty"`
+}
+
+// MarshalJSON renders cred as a W3C VC Data Model 2.0 document, encoding
+// the proof envelope as a DataIntegrityProof.
+func (cred Credential) MarshalJSON() ([]byte, error) {
+	doc := credentialJSON{
+		Context:      vcContext,
+		ID:           cred.ID,
+		Type:         cred.Type,
+		Issuer:       cred.Issuer,
+		IssuanceDate: cred.IssuanceDate,
+		Subject:      cred.Subject,
+		Commitment:   cred.Commitment,
+	}
+
+	if cred.Proof != nil {
+		cryptosuite, err := cryptosuiteForScheme(cred.Proof.Scheme)
+		if err != nil {
+			return nil, err
+		}
+		proofValue, err := encodeProofValue(*cred.Proof, cred.nbPublic, cred.nbSecret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode proof: %v", err)
+		}
+		doc.Proof = &DataIntegrityProof{
+			Type:               "DataIntegrityProof",
+			Cryptosuite:        cryptosuite,
+			Created:            cred.IssuanceDate,
+			VerificationMethod: cred.Issuer + "#zk-vk",
+			ProofPurpose:       "assertionMethod",
+			ProofValue:         proofValue,
+		}
+	}
+
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON decodes a W3C VC Data Model 2.0 document produced by
+// MarshalJSON (or a compatible issuer) back into cred.
+func (cred *Credential) UnmarshalJSON(data []byte) error {
+	var doc credentialJSON
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	*cred = Credential{
+		ID:           doc.ID,
+		Type:         doc.Type,
+		Issuer:       doc.Issuer,
+		IssuanceDate: doc.IssuanceDate,
+		Subject:      doc.Subject,
+		Commitment:   doc.Commitment,
+	}
+
+	if doc.Proof == nil {
+		return nil
+	}
+	scheme, err := schemeForCryptosuite(doc.Proof.Cryptosuite)
+	if err != nil {
+		return err
+	}
+
+	envelope, nbPublic, nbSecret, err := decodeProofValue(doc.Proof.ProofValue, scheme)
+	if err != nil {
+		return fmt.Errorf("failed to decode proof: %v", err)
+	}
+	cred.Proof = envelope
+	cred.nbPublic, cred.nbSecret = nbPublic, nbSecret
+
+	return nil
+}
+
+// encodeProofValue serializes envelope as multibase(base64url) of a
+// [nbPublic nbSecret] header followed by the envelope's raw proof bytes.
+func encodeProofValue(envelope ProofEnvelope, nbPublic, nbSecret int) (string, error) {
+	var buf bytes.Buffer
+	header := [2]uint32{uint32(nbPublic), uint32(nbSecret)}
+	if err := binary.Write(&buf, binary.BigEndian, header); err != nil {
+		return "", err
+	}
+	if _, err := buf.Write(envelope.Bytes); err != nil {
+		return "", err
+	}
+	return multibaseBase64URL(buf.Bytes()), nil
+}
+
+// decodeProofValue is the inverse of encodeProofValue; scheme comes from the
+// DataIntegrityProof's cryptosuite, since the envelope itself carries no
+// header of its own.
+func decodeProofValue(proofValue string, scheme Scheme) (*ProofEnvelope, int, int, error) {
+	raw, err := decodeMultibaseBase64URL(proofValue)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	buf := bytes.NewReader(raw)
+	var header [2]uint32
+	if err := binary.Read(buf, binary.BigEndian, &header); err != nil {
+		return nil, 0, 0, fmt.Errorf("truncated proof header: %v", err)
+	}
+
+	proofBytes := make([]byte, buf.Len())
+	if _, err := buf.Read(proofBytes); err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to read proof bytes: %v", err)
+	}
+
+	return &ProofEnvelope{Scheme: scheme, Bytes: proofBytes}, int(header[0]), int(header[1]), nil
+}
+
+// multibaseBase64URL encodes data per the multibase spec's base64url-no-pad
+// prefix ('u'), as used throughout the Data Integrity spec for proofValue.
+func multibaseBase64URL(data []byte) string {
+	return "u" + base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeMultibaseBase64URL(s string) ([]byte, error) {
+	if len(s) == 0 || s[0] != 'u' {
+		return nil, fmt.Errorf("unsupported multibase prefix in proofValue %q", s)
+	}
+	return base64.RawURLEncoding.DecodeString(s[1:])
+}
+
+// VerifyingKeyRegistry maps issuer DIDs to the Backend they issue proofs
+// under, so a verifier receiving a raw JSON VC can locate the right
+// Setup-populated backend — Groth16 or PLONK — without out-of-band
+// coordination with the issuer beyond the DID itself.
+type VerifyingKeyRegistry struct {
+	mu       sync.RWMutex
+	backends map[string]Backend
+}
+
+// NewVerifyingKeyRegistry returns an empty VerifyingKeyRegistry.
+func NewVerifyingKeyRegistry() *VerifyingKeyRegistry {
+	return &VerifyingKeyRegistry{backends: map[string]Backend{}}
+}
+
+// Register associates issuerDID with backend, overwriting any prior entry.
+func (reg *VerifyingKeyRegistry) Register(issuerDID string, backend Backend) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.backends[issuerDID] = backend
+}
+
+// Lookup returns the Backend registered for issuerDID.
+func (reg *VerifyingKeyRegistry) Lookup(issuerDID string) (Backend, error) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	backend, ok := reg.backends[issuerDID]
+	if !ok {
+		return nil, fmt.Errorf("no backend registered for issuer %q", issuerDID)
+	}
+	return backend, nil
+}
+
+// Export serializes every credential in the wallet's Store as a W3C VC
+// Data Model JSON array, ready to hand to another VC-aware library (e.g. a
+// Nuts-style issuer).
+func (w *Wallet) Export() ([]byte, error) {
+	creds, err := w.store.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credentials: %v", err)
+	}
+	return json.Marshal(creds)
+}
+
+// Import saves every credential decoded from data into the wallet's Store,
+// e.g. a VC bundle received from another issuer. Per-attribute salts for
+// policy-built commitments aren't part of the wire format and are lost on
+// import; presentations against an imported credential must be rebuilt by
+// whichever party retains them.
+func (w *Wallet) Import(data []byte) error {
+	var creds []Credential
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return fmt.Errorf("failed to decode credential bundle: %v", err)
+	}
+	for _, cred := range creds {
+		if err := w.store.Put(cred, nil); err != nil {
+			return fmt.Errorf("failed to save imported credential %s: %v", cred.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+)
+
+// TestCredentialJSONRoundTrip guards MarshalJSON/UnmarshalJSON's
+// DataIntegrityProof encoding: a credential's proof envelope and witness
+// shape (nbPublic/nbSecret) must survive a marshal/unmarshal cycle intact,
+// since decodeProofValue relies entirely on the encoded header to recover
+// them.
+func TestCredentialJSONRoundTrip(t *testing.T) {
+	envelope := ProofEnvelope{Scheme: SchemeGroth16BN254, Bytes: []byte{1, 2, 3, 4, 5}}
+	cred := Credential{
+		ID:       "urn:uuid:test",
+		Type:     []string{"VerifiableCredential", "eIDASIdentityCredential"},
+		Issuer:   "did:example:issuer123",
+		Subject:  map[string]interface{}{"givenName": "Adrien"},
+		Proof:    &envelope,
+		nbPublic: 3,
+		nbSecret: 7,
+	}
+
+	data, err := cred.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+
+	var decoded Credential
+	if err := decoded.UnmarshalJSON(data); err != nil {
+		t.Fatalf("UnmarshalJSON: %v", err)
+	}
+
+	if decoded.ID != cred.ID || decoded.Issuer != cred.Issuer {
+		t.Fatalf("decoded credential identity mismatch: got %+v", decoded)
+	}
+	if decoded.Proof == nil || decoded.Proof.Scheme != envelope.Scheme {
+		t.Fatalf("decoded proof scheme mismatch: got %+v", decoded.Proof)
+	}
+	if string(decoded.Proof.Bytes) != string(envelope.Bytes) {
+		t.Fatalf("decoded proof bytes mismatch: got %v, want %v", decoded.Proof.Bytes, envelope.Bytes)
+	}
+	if decoded.nbPublic != cred.nbPublic || decoded.nbSecret != cred.nbSecret {
+		t.Fatalf("decoded witness shape mismatch: got (%d, %d), want (%d, %d)", decoded.nbPublic, decoded.nbSecret, cred.nbPublic, cred.nbSecret)
+	}
+}
+
+// TestVerifyingKeyRegistry exercises Register/Lookup's happy and
+// not-found paths.
+func TestVerifyingKeyRegistry(t *testing.T) {
+	reg := NewVerifyingKeyRegistry()
+	backend := NewGroth16Backend()
+
+	if _, err := reg.Lookup("did:example:issuer123"); err == nil {
+		t.Fatal("Lookup succeeded before Register, want an error")
+	}
+
+	reg.Register("did:example:issuer123", backend)
+
+	got, err := reg.Lookup("did:example:issuer123")
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	if got != Backend(backend) {
+		t.Fatal("Lookup returned a different Backend than was registered")
+	}
+}
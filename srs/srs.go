@@ -0,0 +1,48 @@
+// Package srs loads a universal KZG structured reference string once at
+// startup, so every PLONK circuit built afterwards (see PlonkBackend) can
+// reuse it instead of running a fresh trusted setup per circuit shape.
+package srs
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/kzg"
+)
+
+// KZG holds both bases of a loaded SRS: Canonical for verification and
+// Lagrange for proving, as gnark's PLONK backend requires both.
+type KZG struct {
+	Canonical kzg.SRS
+	Lagrange  kzg.SRS
+}
+
+// Load reads a Powers-of-Tau ceremony file at path, sized for circuits of
+// up to maxConstraints constraints, and derives the Lagrange-basis SRS
+// PLONK proving needs alongside the canonical one used for verification.
+func Load(path string, maxConstraints int) (KZG, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return KZG{}, fmt.Errorf("failed to open SRS file %s: %v", path, err)
+	}
+	defer f.Close()
+
+	var canonical kzg.SRS
+	if _, err := canonical.ReadFrom(f); err != nil {
+		return KZG{}, fmt.Errorf("failed to decode canonical SRS from %s: %v", path, err)
+	}
+
+	size := ecc.NextPowerOfTwo(uint64(maxConstraints))
+	if size+3 > uint64(len(canonical.Pk.G1)) {
+		return KZG{}, fmt.Errorf("SRS at %s is too small for %d constraints", path, maxConstraints)
+	}
+
+	lagrangeG1, err := kzg.ToLagrangeG1(canonical.Pk.G1[:size])
+	if err != nil {
+		return KZG{}, fmt.Errorf("failed to derive Lagrange-basis SRS: %v", err)
+	}
+	lagrange := kzg.SRS{Pk: kzg.ProvingKey{G1: lagrangeG1}, Vk: canonical.Vk}
+
+	return KZG{Canonical: canonical, Lagrange: lagrange}, nil
+}
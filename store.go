@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+)
+
+// Store is the pluggable persistence layer behind Wallet. Everything the
+// wallet needs to survive a process restart — credentials, the per-
+// attribute salts backing policy commitments, and raw proving/verifying
+// key or constraint-system material — goes through it instead of a bare
+// in-memory slice.
+type Store interface {
+	// Put saves cred and its associated attribute salts (nil for
+	// credentials with no commitment), overwriting any existing entry with
+	// the same ID.
+	Put(cred Credential, salts []*big.Int) error
+	// Get returns the credential and salts previously saved under id.
+	Get(id string) (Credential, []*big.Int, error)
+	// List returns every stored credential, in no particular order.
+	List() ([]Credential, error)
+	// Delete removes the credential stored under id. It is a no-op if id
+	// isn't present.
+	Delete(id string) error
+	// Query returns every stored credential matching policy: attributes
+	// named by a PredicateRange or PredicateSetMembership entry must
+	// satisfy it in the clear (Query is a local convenience filter, not a
+	// proof, so it only ever sees plaintext Subject values already held by
+	// the wallet).
+	Query(policy []PresentationPolicy) ([]Credential, error)
+
+	// PutArtifact saves a serialized ProvingKey, VerifyingKey or
+	// ConstraintSystem under name, via its own io.WriterTo encoding.
+	PutArtifact(name string, artifact io.WriterTo) error
+	// GetArtifact decodes the artifact saved under name into artifact, via
+	// its io.ReaderFrom.
+	GetArtifact(name string, artifact io.ReaderFrom) error
+}
+
+// memoryStore is the zero-configuration Store a Wallet falls back to when
+// NewWallet isn't given WithStore or WithPassphrase: everything lives only
+// for the life of the process, same as the original Wallet.Credentials
+// slice.
+type memoryStore struct {
+	mu    sync.RWMutex
+	creds map[string]Credential
+	salts map[string][]*big.Int
+	blobs map[string][]byte
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		creds: map[string]Credential{},
+		salts: map[string][]*big.Int{},
+		blobs: map[string][]byte{},
+	}
+}
+
+func (s *memoryStore) Put(cred Credential, salts []*big.Int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.creds[cred.ID] = cred
+	if salts != nil {
+		s.salts[cred.ID] = salts
+	}
+	return nil
+}
+
+func (s *memoryStore) Get(id string) (Credential, []*big.Int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cred, ok := s.creds[id]
+	if !ok {
+		return Credential{}, nil, fmt.Errorf("no credential with id %q", id)
+	}
+	return cred, s.salts[id], nil
+}
+
+func (s *memoryStore) List() ([]Credential, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Credential, 0, len(s.creds))
+	for _, c := range s.creds {
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func (s *memoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.creds, id)
+	delete(s.salts, id)
+	return nil
+}
+
+func (s *memoryStore) Query(policy []PresentationPolicy) ([]Credential, error) {
+	all, _ := s.List()
+	return filterByPolicy(all, policy), nil
+}
+
+func (s *memoryStore) PutArtifact(name string, artifact io.WriterTo) error {
+	var buf bytes.Buffer
+	if _, err := artifact.WriteTo(&buf); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[name] = buf.Bytes()
+	return nil
+}
+
+func (s *memoryStore) GetArtifact(name string, artifact io.ReaderFrom) error {
+	s.mu.RLock()
+	raw, ok := s.blobs[name]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("no artifact named %q", name)
+	}
+	_, err := artifact.ReadFrom(bytes.NewReader(raw))
+	return err
+}
+
+// filterByPolicy returns the subset of creds matching every entry of
+// policy; see Store.Query.
+func filterByPolicy(creds []Credential, policy []PresentationPolicy) []Credential {
+	var out []Credential
+	for _, c := range creds {
+		if matchesPolicy(c, policy) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func matchesPolicy(cred Credential, policy []PresentationPolicy) bool {
+	for _, p := range policy {
+		value, err := attributeAsBigInt(cred.Subject, p.Attribute)
+		if err != nil {
+			return false
+		}
+
+		switch p.Predicate {
+		case PredicateRange:
+			min, minOK := policyInt64(p.PublicParams, "min")
+			max, maxOK := policyInt64(p.PublicParams, "max")
+			if !minOK || !maxOK || value.Cmp(big.NewInt(min)) < 0 || value.Cmp(big.NewInt(max)) > 0 {
+				return false
+			}
+		case PredicateSetMembership:
+			allowed, _ := p.PublicParams["allowed"].([]int64)
+			match := false
+			for _, a := range allowed {
+				if value.Cmp(big.NewInt(a)) == 0 {
+					match = true
+					break
+				}
+			}
+			if !match {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func policyInt64(params map[string]interface{}, key string) (int64, bool) {
+	switch v := params[key].(type) {
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case float64:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
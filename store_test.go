@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+	"path/filepath"
+	"testing"
+)
+
+// artifactStub is a minimal io.WriterTo/io.ReaderFrom, standing in for a
+// real groth16.ProvingKey/VerifyingKey/ConstraintSystem so
+// PutArtifact/GetArtifact can be exercised without running an actual
+// trusted setup.
+type artifactStub struct {
+	data []byte
+}
+
+func (a *artifactStub) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(a.data)
+	return int64(n), err
+}
+
+func (a *artifactStub) ReadFrom(r io.Reader) (int64, error) {
+	data, err := io.ReadAll(r)
+	a.data = data
+	return int64(len(data)), err
+}
+
+// testCredential returns a minimal credential plus salts for exercising a
+// Store implementation's Put/Get/List/Delete/Query.
+func testCredential(id string) (Credential, []*big.Int) {
+	cred := Credential{
+		ID:      id,
+		Issuer:  "did:example:issuer123",
+		Subject: map[string]interface{}{"birthYear": "1984"},
+	}
+	salts := []*big.Int{big.NewInt(1), big.NewInt(2)}
+	return cred, salts
+}
+
+func testStore(t *testing.T, store Store) {
+	t.Helper()
+
+	cred, salts := testCredential("urn:uuid:store-test")
+	if err := store.Put(cred, salts); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	got, gotSalts, err := store.Get(cred.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.ID != cred.ID || got.Issuer != cred.Issuer {
+		t.Fatalf("Get returned %+v, want %+v", got, cred)
+	}
+	if len(gotSalts) != len(salts) || gotSalts[0].Cmp(salts[0]) != 0 {
+		t.Fatalf("Get returned salts %v, want %v", gotSalts, salts)
+	}
+
+	if _, _, err := store.Get("urn:uuid:missing"); err == nil {
+		t.Fatal("Get succeeded for a missing credential, want an error")
+	}
+
+	all, err := store.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(all) != 1 {
+		t.Fatalf("List returned %d credentials, want 1", len(all))
+	}
+
+	policy := []PresentationPolicy{
+		{Attribute: "birthYear", Predicate: PredicateRange, PublicParams: map[string]interface{}{"min": int64(1900), "max": int64(2000)}},
+	}
+	matches, err := store.Query(policy)
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("Query returned %d credentials, want 1", len(matches))
+	}
+
+	excluding := []PresentationPolicy{
+		{Attribute: "birthYear", Predicate: PredicateRange, PublicParams: map[string]interface{}{"min": int64(1985), "max": int64(2000)}},
+	}
+	if matches, err := store.Query(excluding); err != nil || len(matches) != 0 {
+		t.Fatalf("Query(excluding) = %v, %v, want 0 matches", matches, err)
+	}
+
+	if err := store.Delete(cred.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, _, err := store.Get(cred.ID); err == nil {
+		t.Fatal("Get succeeded after Delete, want an error")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, newMemoryStore())
+}
+
+func TestMemoryStoreArtifacts(t *testing.T) {
+	store := newMemoryStore()
+
+	pk := artifactStub{data: []byte("proving key bytes")}
+	if err := store.PutArtifact("agecheck.pk", &pk); err != nil {
+		t.Fatalf("PutArtifact: %v", err)
+	}
+
+	var loaded artifactStub
+	if err := store.GetArtifact("agecheck.pk", &loaded); err != nil {
+		t.Fatalf("GetArtifact: %v", err)
+	}
+	if !bytes.Equal(loaded.data, pk.data) {
+		t.Fatalf("GetArtifact returned %q, want %q", loaded.data, pk.data)
+	}
+
+	if err := store.GetArtifact("missing", &loaded); err == nil {
+		t.Fatal("GetArtifact succeeded for a missing artifact, want an error")
+	}
+}
+
+func TestBoltStore(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "wallet.db")
+	store, err := NewBoltStore(dbPath, "test-passphrase")
+	if err != nil {
+		t.Fatalf("NewBoltStore: %v", err)
+	}
+	defer store.Close()
+
+	testStore(t, store)
+}
+
+func TestBoltStoreWrongPassphrase(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "wallet.db")
+	store, err := NewBoltStore(dbPath, "correct-passphrase")
+	if err != nil {
+		t.Fatalf("NewBoltStore: %v", err)
+	}
+	cred, salts := testCredential("urn:uuid:wrong-passphrase")
+	if err := store.Put(cred, salts); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	store.Close()
+
+	reopened, err := NewBoltStore(dbPath, "wrong-passphrase")
+	if err != nil {
+		t.Fatalf("NewBoltStore (reopen): %v", err)
+	}
+	defer reopened.Close()
+
+	if _, _, err := reopened.Get(cred.ID); err == nil {
+		t.Fatal("Get decrypted a credential under the wrong passphrase, want an error")
+	}
+}
@@ -8,14 +8,30 @@ import (
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
 )
 
+// r1csBuilder is frontend.Compile's expected NewBuilder, with r1cs.NewBuilder's
+// generic element type pinned to the native field (the same instantiation its
+// own package tests use).
+var r1csBuilder = r1cs.NewBuilder[constraint.U64]
+
+// scsBuilder is frontend.Compile's expected NewBuilder for PlonkBackend:
+// gnark's PLONK setup operates over a sparse R1CS, not the R1CS r1csBuilder
+// produces for Groth16, so any circuit proved under PlonkBackend must be
+// compiled against this builder instead (see CompileForPlonk).
+var scsBuilder = scs.NewBuilder[constraint.U64]
+
 // Inspired by https://arriqaaq.substack.com/p/unlocking-the-power-of-zero-knowledge
 
 // AgeCheckCircuit defines the relationship between inputs (BirthYear, CurrentYear) and the condition (age > 18).
 type AgeCheckCircuit struct {
 	BirthYear   frontend.Variable `gnark:"birthYear,secret"`   // private input
 	CurrentYear frontend.Variable `gnark:"currentYear,public"` // public input
+
+	// Revocation proves the credential this circuit is proving hasn't been
+	// added to the issuer's published revocation tree since issuance.
+	Revocation revocationWitness
 }
 
 // Define declares the circuit constraints
@@ -27,13 +43,13 @@ func (circuit *AgeCheckCircuit) Define(api frontend.API) error {
 	isOver18 := api.Cmp(age, 18)
 	api.AssertIsEqual(isOver18, 1)
 
-	return nil
+	return assertNonRevoked(api, &circuit.Revocation)
 }
 
 func InitCircuit() (groth16.ProvingKey, groth16.VerifyingKey, constraint.ConstraintSystem, error) {
 	var circuit AgeCheckCircuit
 
-	builder := r1cs.NewBuilder
+	builder := r1csBuilder
 	r1cs, err := frontend.Compile(ecc.BN254.ScalarField(), builder, &circuit)
 	if err != nil {
 		fmt.Printf("Failed to compile circuit: %v\n", err)
@@ -49,10 +65,54 @@ func InitCircuit() (groth16.ProvingKey, groth16.VerifyingKey, constraint.Constra
 	return pk, vk, r1cs, err
 }
 
-func generateZKProof(pk groth16.ProvingKey, r1cs constraint.ConstraintSystem, birthYear, currentYear int) (*groth16.Proof, *AgeCheckCircuit, error) {
+// Artifact names AgeCheckCircuit's proving key, verifying key and
+// constraint system are saved under via Wallet.SaveArtifact/LoadArtifact.
+const (
+	ageCheckPKArtifact = "agecheck.pk"
+	ageCheckVKArtifact = "agecheck.vk"
+	ageCheckCSArtifact = "agecheck.ccs"
+)
+
+// LoadOrInitCircuit returns AgeCheckCircuit's proving key, verifying key and
+// constraint system, reusing copies already persisted in w's Store instead
+// of re-running InitCircuit's trusted setup on every call.
+func LoadOrInitCircuit(w *Wallet) (groth16.ProvingKey, groth16.VerifyingKey, constraint.ConstraintSystem, error) {
+	pk := groth16.NewProvingKey(ecc.BN254)
+	vk := groth16.NewVerifyingKey(ecc.BN254)
+	ccs := groth16.NewCS(ecc.BN254)
+
+	err := w.LoadArtifact(ageCheckPKArtifact, pk)
+	if err == nil {
+		err = w.LoadArtifact(ageCheckVKArtifact, vk)
+	}
+	if err == nil {
+		err = w.LoadArtifact(ageCheckCSArtifact, ccs)
+	}
+	if err == nil {
+		return pk, vk, ccs, nil
+	}
+
+	pk, vk, ccs, err = InitCircuit()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := w.SaveArtifact(ageCheckPKArtifact, pk); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to persist proving key: %v", err)
+	}
+	if err := w.SaveArtifact(ageCheckVKArtifact, vk); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to persist verifying key: %v", err)
+	}
+	if err := w.SaveArtifact(ageCheckCSArtifact, ccs); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to persist constraint system: %v", err)
+	}
+	return pk, vk, ccs, nil
+}
+
+func generateZKProof(pk groth16.ProvingKey, r1cs constraint.ConstraintSystem, birthYear, currentYear int, revocation *revocationWitness) (*groth16.Proof, *AgeCheckCircuit, error) {
 	assignment := &AgeCheckCircuit{
 		BirthYear:   birthYear,   // secret value (kept private)
 		CurrentYear: currentYear, // public value
+		Revocation:  *revocation,
 	}
 
 	witness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())